@@ -4,18 +4,23 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	chatclient "Void/internal/client"
+	"Void/internal/identity"
 	"Void/internal/keyverify"
+	"Void/internal/roomauth"
 	"Void/internal/server"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 type App struct {
-	ctx    context.Context
-	client *chatclient.ChatClient
-	mu     sync.Mutex
+	ctx      context.Context
+	client   *chatclient.ChatClient
+	identity *identity.Identity
+	trust    *keyverify.TrustStore
+	mu       sync.Mutex
 }
 
 func NewApp() *App {
@@ -24,41 +29,88 @@ func NewApp() *App {
 
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+	a.trust = keyverify.NewTrustStore()
+
+	path, err := keyverify.DefaultTrustStorePath()
+	if err != nil {
+		runtime.LogErrorf(ctx, "trust store unavailable: %v", err)
+		return
+	}
+	trust, err := keyverify.OpenTrustStore(path)
+	if err != nil {
+		runtime.LogErrorf(ctx, "trust store unavailable: %v", err)
+		return
+	}
+	a.trust = trust
 }
 
-func (a *App) ConnectToRoom(serverAddress string, roomID string, username string, password string) (string, error) {
+func (a *App) HasIdentity() bool {
+	path, err := identity.DefaultPath()
+	if err != nil {
+		return false
+	}
+	return identity.Exists(path)
+}
+
+func (a *App) UnlockIdentity(passphrase string) error {
+	path, err := identity.DefaultPath()
+	if err != nil {
+		return err
+	}
+
+	id, err := identity.LoadOrCreate(path, passphrase)
+	if err != nil {
+		return err
+	}
+
 	a.mu.Lock()
-	defer a.mu.Unlock()
+	a.identity = id
+	a.mu.Unlock()
+	return nil
+}
+
+func (a *App) ConnectToRoom(serverAddress string, roomID string, username string, password string) (string, error) {
+	return a.connect(serverAddress, roomID, username, password, false)
+}
+
+func (a *App) CreateRoom(serverAddress string, roomID string, username string, password string) (string, error) {
+	return a.connect(serverAddress, roomID, username, password, true)
+}
 
-	if a.client != nil {
-		a.client.Close()
+func (a *App) connect(serverAddress string, roomID string, username string, password string, create bool) (string, error) {
+	a.mu.Lock()
+	id := a.identity
+	a.mu.Unlock()
+	if id == nil {
+		return "", fmt.Errorf("identity locked")
 	}
 
-	client, err := chatclient.NewChatClient(username)
+	client, err := chatclient.NewChatClient(username, id)
 	if err != nil {
 		return "", err
 	}
+	client.SetTrustStore(a.trust)
 
 	client.SetOnMessage(func(userID string, username string, content string) {
 		runtime.EventsEmit(a.ctx, "message", userID, username, content)
 	})
 
-	client.SetOnPeerJoin(func(userID string, username string, publicKey [32]byte) {
-		fingerprint := keyverify.ComputeKeyFingerprint(&publicKey)
+	client.SetOnPeerJoin(func(userID string, username string, identityKey [32]byte) {
+		fingerprint := keyverify.ComputeKeyFingerprint(&identityKey)
 		runtime.EventsEmit(a.ctx, "peerJoin", userID, username, fingerprint)
 	})
 
-	client.SetOnPeerLeft(func(userID string) {
-		runtime.EventsEmit(a.ctx, "peerLeft", userID)
+	client.SetOnPeerLeft(func(userID string, reason string) {
+		runtime.EventsEmit(a.ctx, "peerLeft", userID, reason)
 	})
 
 	client.SetOnRoomResponse(func(peers []chatclient.PeerInfo) {
 		myUserID := client.GetUserID()
 		runtime.EventsEmit(a.ctx, "myUserId", myUserID)
 		for _, peer := range peers {
-			publicKey, exists := client.GetPeerKey(peer.UserID)
+			identityKey, exists := client.GetPeerIdentityKey(peer.UserID)
 			if exists {
-				fingerprint := keyverify.ComputeKeyFingerprint(&publicKey)
+				fingerprint := keyverify.ComputeKeyFingerprint(&identityKey)
 				runtime.EventsEmit(a.ctx, "peerJoin", peer.UserID, peer.Username, fingerprint)
 			} else {
 				runtime.EventsEmit(a.ctx, "peerJoin", peer.UserID, peer.Username, "")
@@ -70,13 +122,56 @@ func (a *App) ConnectToRoom(serverAddress string, roomID string, username string
 		runtime.EventsEmit(a.ctx, "keyMismatch", userID, username, expectedFingerprint, receivedFingerprint)
 	})
 
-	client.SetOnRoomError(func(message string) {
-		runtime.EventsEmit(a.ctx, "roomError", message)
+	client.SetOnIdentityRenamed(func(userID string, username string, previousUsername string) {
+		runtime.EventsEmit(a.ctx, "securityEvent", "identityRenamed", userID, username, previousUsername)
+	})
+
+	client.SetOnTrustStoreError(func(err error) {
+		runtime.LogErrorf(a.ctx, "trust store not saved: %v", err)
+	})
+
+	client.SetOnRoomError(func(code string, message string) {
+		runtime.EventsEmit(a.ctx, "roomError", code, message)
+	})
+
+	client.SetOnInvalidMembership(func(userID string, username string) {
+		runtime.EventsEmit(a.ctx, "securityEvent", "invalidMembership", userID, username)
+	})
+
+	client.SetOnServerError(func(code string, detail string, requestID string) {
+		runtime.EventsEmit(a.ctx, "serverError", code, detail, requestID)
+	})
+
+	client.SetOnUndelivered(func(messageID string, recipients []string) {
+		runtime.EventsEmit(a.ctx, "undelivered", messageID, recipients)
 	})
 
+	client.SetOnStateChange(func(change chatclient.StateChange) {
+		runtime.EventsEmit(a.ctx, "connectionState", change)
+	})
+
+	client.SetOnRateLimited(func(scope string, retryAfter time.Duration) {
+		runtime.EventsEmit(a.ctx, "rateLimited", scope, retryAfter.Milliseconds())
+	})
+
+	client.SetOnMessagesDropped(func(count int) {
+		runtime.EventsEmit(a.ctx, "messagesDropped", count)
+	})
+
+	a.mu.Lock()
+	previous := a.client
 	a.client = client
+	a.mu.Unlock()
+
+	if previous != nil {
+		previous.Close()
+	}
 
-	if err := client.Connect(serverAddress, roomID, password); err != nil {
+	connect := client.Connect
+	if create {
+		connect = client.CreateRoom
+	}
+	if err := connect(serverAddress, roomID, password); err != nil {
 		return "", err
 	}
 
@@ -105,62 +200,129 @@ func (a *App) GenerateRoomID() string {
 	return server.GenerateRoomID()
 }
 
+func (a *App) GenerateInvite() (string, error) {
+	return roomauth.NewInvite(server.GenerateRoomID())
+}
+
 func (a *App) GetMyPublicKeyFingerprint() string {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	if a.client == nil {
+	if a.identity == nil {
 		return ""
 	}
 
-	publicKey := a.client.GetPublicKey()
-	return keyverify.ComputeKeyFingerprint(&publicKey)
+	identityKey := a.identity.PublicKeyArray()
+	return keyverify.ComputeKeyFingerprint(&identityKey)
 }
 
 func (a *App) Disconnect() error {
 	a.mu.Lock()
-	defer a.mu.Unlock()
+	client := a.client
+	a.client = nil
+	a.mu.Unlock()
 
-	if a.client != nil {
-		err := a.client.Close()
-		a.client = nil
-		return err
+	if client != nil {
+		return client.Close()
 	}
 	return nil
 }
 
-func (a *App) SetPeerFingerprint(userID string, fingerprint string) {
+func (a *App) GetConnectionState() chatclient.StateChange {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	if a.client != nil {
-		a.client.SetKnownFingerprint(userID, fingerprint)
+	if a.client == nil {
+		return chatclient.StateChange{State: chatclient.StateIdle}
 	}
+	return a.client.GetState()
 }
 
-func (a *App) GetPeerFingerprint(userID string) string {
+func (a *App) GetPeerKeyFingerprint(userID string) string {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	if a.client != nil {
-		fingerprint, _ := a.client.GetKnownFingerprint(userID)
-		return fingerprint
+	if a.client == nil {
+		return ""
+	}
+
+	identityKey, exists := a.client.GetPeerIdentityKey(userID)
+	if !exists {
+		return ""
+	}
+
+	return keyverify.ComputeKeyFingerprint(&identityKey)
+}
+
+func (a *App) ListTrustRecords() []keyverify.TrustRecord {
+	return a.trust.List()
+}
+
+func (a *App) VerifyPeer(fingerprint string) error {
+	return a.trust.Verify(fingerprint)
+}
+
+func (a *App) RevokePeer(fingerprint string) error {
+	return a.trust.Revoke(fingerprint)
+}
+
+func (a *App) ExportTrustRecords() (string, error) {
+	data, err := a.trust.Export()
+	if err != nil {
+		return "", err
 	}
-	return ""
+	return string(data), nil
 }
 
-func (a *App) GetPeerKeyFingerprint(userID string) string {
+func (a *App) GetSafetyNumber(userID string) (string, error) {
+	local, remote, err := a.conversationKeys(userID)
+	if err != nil {
+		return "", err
+	}
+	return keyverify.FormatSafetyNumber(keyverify.ComputeSafetyNumber(&local, &remote)), nil
+}
+
+func (a *App) GetVerificationPayload(userID string) (string, error) {
+	local, remote, err := a.conversationKeys(userID)
+	if err != nil {
+		return "", err
+	}
+	return keyverify.EncodeVerificationURI(&local, &remote), nil
+}
+
+func (a *App) VerifyPayload(payload string) (string, error) {
+	a.mu.Lock()
+	id := a.identity
+	a.mu.Unlock()
+
+	if id == nil {
+		return "", fmt.Errorf("identity locked")
+	}
+
+	local := id.PublicKeyArray()
+	sender, err := keyverify.ResolveVerificationURI(payload, &local)
+	if err != nil {
+		return "", err
+	}
+
+	fingerprint := keyverify.ComputeKeyFingerprint(&sender)
+	if err := a.trust.Verify(fingerprint); err != nil {
+		return "", err
+	}
+	return fingerprint, nil
+}
+
+func (a *App) conversationKeys(userID string) ([32]byte, [32]byte, error) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
 	if a.client == nil {
-		return ""
+		return [32]byte{}, [32]byte{}, fmt.Errorf("not connected")
 	}
 
-	publicKey, exists := a.client.GetPeerKey(userID)
+	remote, exists := a.client.GetPeerIdentityKey(userID)
 	if !exists {
-		return ""
+		return [32]byte{}, [32]byte{}, fmt.Errorf("unknown peer")
 	}
-
-	return keyverify.ComputeKeyFingerprint(&publicKey)
+	return a.client.GetIdentityKey(), remote, nil
 }
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"Void/internal/config"
+	"Void/internal/server"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+func configCommand(args []string) int {
+	if len(args) == 0 || args[0] != "validate" {
+		fmt.Fprintln(os.Stderr, "usage: void-server config validate [-config] <path>")
+		return 2
+	}
+
+	fs := flag.NewFlagSet("config validate", flag.ContinueOnError)
+	path := fs.String("config", "", "YAML config file")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if *path == "" {
+		*path = fs.Arg(0)
+	}
+	if *path == "" {
+		fmt.Fprintln(os.Stderr, "usage: void-server config validate [-config] <path>")
+		return 2
+	}
+
+	if _, err := config.Load(*path); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: invalid\n%v\n", *path, err)
+		return 1
+	}
+	fmt.Printf("%s: OK\n", *path)
+	return 0
+}
+
+func loadConfig(path string) (*config.Config, error) {
+	cfg := config.Default()
+	if path != "" {
+		loaded, err := config.Load(path)
+		if err != nil {
+			return nil, err
+		}
+		cfg = loaded
+	}
+
+	applyFlags(cfg)
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func applyFlags(cfg *config.Config) {
+	set := make(map[string]string)
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Name] = f.Value.String()
+	})
+	lookup := func(name string) string {
+		return flag.Lookup(name).Value.String()
+	}
+	duration := func(name string, target *time.Duration) {
+		if value, ok := set[name]; ok {
+			*target, _ = time.ParseDuration(value)
+		}
+	}
+
+	_, bindSet := set["bind"]
+	if _, ok := set["port"]; ok || bindSet {
+		host, port, _ := net.SplitHostPort(cfg.Listen.Address)
+		if bindSet {
+			host = lookup("bind")
+		}
+		if ok {
+			port = lookup("port")
+		}
+		cfg.Listen.Address = net.JoinHostPort(host, port)
+	}
+	if wsPort, ok := set["ws-port"]; ok {
+		cfg.Listen.WebSocket = ""
+		if wsPort != "" {
+			host, _, _ := net.SplitHostPort(cfg.Listen.Address)
+			cfg.Listen.WebSocket = net.JoinHostPort(host, wsPort)
+		}
+	}
+
+	if value, ok := set["metrics-addr"]; ok {
+		cfg.Listen.Metrics = value
+	}
+	if value, ok := set["admin-socket"]; ok {
+		cfg.Listen.AdminSocket = value
+	}
+	if value, ok := set["trusted-proxies"]; ok {
+		cfg.Listen.TrustedProxies = nil
+		for _, proxy := range strings.Split(value, ",") {
+			if proxy = strings.TrimSpace(proxy); proxy != "" {
+				cfg.Listen.TrustedProxies = append(cfg.Listen.TrustedProxies, proxy)
+			}
+		}
+	}
+
+	if value, ok := set["tls-cert"]; ok {
+		cfg.TLS.Cert = value
+	}
+	if value, ok := set["tls-key"]; ok {
+		cfg.TLS.Key = value
+	}
+	if value, ok := set["tls-self-signed"]; ok {
+		cfg.TLS.SelfSigned = value == "true"
+	}
+
+	duration("handshake-timeout", &cfg.Timeouts.Handshake)
+	duration("idle-timeout", &cfg.Timeouts.Idle)
+	duration("write-timeout", &cfg.Timeouts.Write)
+	duration("resume-window", &cfg.Timeouts.ResumeWindow)
+	duration("drain-timeout", &cfg.Shutdown.Drain)
+	duration("shutdown-timeout", &cfg.Shutdown.Timeout)
+	duration("reconnect-after", &cfg.Shutdown.ReconnectAfter)
+	duration("shutdown-grace", &cfg.Shutdown.Grace)
+	if value, ok := set["alternate-address"]; ok {
+		cfg.Shutdown.AlternateAddress = value
+	}
+}
+
+func reload(s *server.Server, current *config.Config, path string) *config.Config {
+	if path == "" {
+		log.Printf("Received SIGHUP but no config file was given, nothing to reload")
+		return current
+	}
+
+	next, err := loadConfig(path)
+	if err != nil {
+		log.Printf("Config reload failed, keeping previous settings: %v", err)
+		return current
+	}
+
+	if fields := current.RestartRequired(next); len(fields) > 0 {
+		log.Printf("Config reload: %s changed, restart to apply", strings.Join(fields, ", "))
+		proxies := next.Listen.TrustedProxies
+		next.Listen = current.Listen
+		next.Listen.TrustedProxies = proxies
+		next.TLS = current.TLS
+		next.Limits.MaxFrameSize = current.Limits.MaxFrameSize
+	}
+
+	logger, err := setupLogging(next)
+	if err != nil {
+		log.Printf("Config reload: cannot open log file, keeping previous logging: %v", err)
+		next.Log = current.Log
+		logger = slog.Default()
+	}
+
+	opts := next.ServerOptions()
+	opts.Logger = logger
+	s.Reload(opts)
+	log.Printf("Config reloaded from %s", path)
+	return next
+}
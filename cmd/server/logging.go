@@ -0,0 +1,49 @@
+package main
+
+import (
+	"Void/internal/config"
+	"Void/internal/logging"
+	"io"
+	"log/slog"
+	"os"
+)
+
+var (
+	logLevel = new(slog.LevelVar)
+	logFile  *logging.RotatingFile
+)
+
+func setupLogging(cfg *config.Config) (*slog.Logger, error) {
+	maxSize := int64(cfg.Log.MaxSizeMB) << 20
+
+	var w io.Writer = os.Stderr
+	switch {
+	case cfg.Log.File == "":
+		if logFile != nil {
+			defer logFile.Close()
+			logFile = nil
+		}
+	case logFile != nil && logFile.Path() == cfg.Log.File:
+		logFile.SetLimits(maxSize, cfg.Log.MaxFiles, cfg.Log.MaxAge)
+		if err := logFile.Reopen(); err != nil {
+			return nil, err
+		}
+		w = logFile
+	default:
+		file, err := logging.OpenRotating(cfg.Log.File, maxSize, cfg.Log.MaxFiles, cfg.Log.MaxAge)
+		if err != nil {
+			return nil, err
+		}
+		if logFile != nil {
+			defer logFile.Close()
+		}
+		logFile = file
+		w = file
+	}
+
+	level, _ := cfg.LogLevel()
+	logLevel.Set(level)
+	logger := logging.New(w, cfg.Log.Format, logLevel)
+	slog.SetDefault(logger)
+	return logger, nil
+}
@@ -1,19 +1,158 @@
 package main
 
 import (
+	"Void/internal/config"
 	"Void/internal/server"
+	"Void/internal/tlsutil"
+	"context"
+	"crypto/tls"
 	"flag"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func main() {
-	port := flag.String("port", "8080", "Server port")
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "config":
+			os.Exit(configCommand(os.Args[2:]))
+		case "status":
+			os.Exit(statusCommand(os.Args[2:]))
+		case "drain":
+			os.Exit(drainCommand(os.Args[2:]))
+		}
+	}
+
+	configPath := flag.String("config", "", "YAML config file (flags override its settings)")
+	flag.String("bind", "", "Address to bind to (all interfaces when empty)")
+	flag.String("port", "8080", "Server port")
+	flag.String("ws-port", "", "WebSocket port (serves /ws, disabled when empty)")
+	flag.String("metrics-addr", "", "Address for the Prometheus /metrics endpoint (disabled when empty)")
+	flag.String("trusted-proxies", "", "Comma-separated proxy IPs or CIDR ranges whose X-Forwarded-For is trusted on the WebSocket listener")
+	flag.String("admin-socket", config.DefaultAdminSocket, "Unix socket for the status command (disabled when empty)")
+	flag.String("tls-cert", "", "TLS certificate file")
+	flag.String("tls-key", "", "TLS private key file")
+	flag.Bool("tls-self-signed", false, "Serve TLS with a self-signed certificate, generated on first run")
+	flag.Duration("handshake-timeout", server.DefaultHandshakeTimeout, "Time a new connection has to join a room")
+	flag.Duration("idle-timeout", server.DefaultIdleTimeout, "Disconnect clients silent for this long")
+	flag.Duration("write-timeout", server.DefaultWriteTimeout, "Deadline for each write to a client")
+	flag.Duration("resume-window", server.DefaultResumeWindow, "How long a dropped client can resume its session (0 disables)")
+	flag.Duration("drain-timeout", 0, "On shutdown, keep existing rooms running this long while refusing new ones")
+	flag.Duration("shutdown-timeout", config.DefaultShutdownTimeout, "Time allowed to flush pending messages on shutdown")
+	flag.Duration("shutdown-grace", server.DefaultShutdownGrace, "Time clients get to act on the shutdown notice before the server stops reading")
+	flag.Duration("reconnect-after", 0, "Tell clients to wait this long before reconnecting after a shutdown")
+	flag.String("alternate-address", "", "Tell clients to reconnect to this address after a shutdown")
 	flag.Parse()
 
-	s := server.NewServer(*port)
-	log.Printf("Starting Void server on port %s", *port)
-	if err := s.Start(); err != nil {
-		log.Fatalf("Server error: %v", err)
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Config error: %v", err)
+	}
+	logger, err := setupLogging(cfg)
+	if err != nil {
+		log.Fatalf("Log error: %v", err)
+	}
+
+	opts := cfg.ServerOptions()
+	opts.Logger = logger
+	opts.Version = buildVersion()
+	if cfg.TLS.SelfSigned || cfg.TLS.Cert != "" || cfg.TLS.Key != "" {
+		cert, err := loadCertificate(cfg.TLS.Cert, cfg.TLS.Key, cfg.TLS.SelfSigned)
+		if err != nil {
+			log.Fatalf("TLS error: %v", err)
+		}
+		log.Printf("TLS certificate fingerprint (sha256): %s", tlsutil.Fingerprint(cert.Certificate[0]))
+		opts.TLSConfig = tlsutil.ServerConfig(cert)
+	}
+
+	s := server.New(opts)
+
+	errs := make(chan error, 4)
+	if opts.WebSocketAddress != "" {
+		go func() {
+			errs <- s.StartWebSocket()
+		}()
+	}
+	if opts.MetricsAddress != "" {
+		go func() {
+			errs <- s.StartMetrics()
+		}()
+	}
+	if opts.AdminSocket != "" {
+		go func() {
+			errs <- s.StartAdmin()
+		}()
+	}
+
+	log.Printf("Starting Void server %s on %s", opts.Version, opts.Address)
+	go func() {
+		errs <- s.Start()
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+
+	for stopped := false; !stopped; {
+		select {
+		case err := <-errs:
+			log.Fatalf("Server error: %v", err)
+		case sig := <-signals:
+			if sig == syscall.SIGHUP {
+				cfg = reload(s, cfg, *configPath)
+				continue
+			}
+			log.Printf("Received %v", sig)
+			stopped = true
+		}
 	}
+
+	if cfg.Shutdown.Drain > 0 {
+		log.Printf("Draining for up to %v (send the signal again to stop now)", cfg.Shutdown.Drain)
+		cfg = drain(s, cfg, *configPath, signals)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Shutdown.Timeout)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		log.Printf("Shutdown error: %v", err)
+	}
+	log.Printf("Server stopped")
 }
 
+func drain(s *server.Server, cfg *config.Config, configPath string, signals <-chan os.Signal) *config.Config {
+	s.Drain()
+
+	deadline := time.After(cfg.Shutdown.Drain)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for s.RoomCount() > 0 {
+		select {
+		case <-deadline:
+			return cfg
+		case sig := <-signals:
+			if sig != syscall.SIGHUP {
+				return cfg
+			}
+			cfg = reload(s, cfg, configPath)
+		case <-ticker.C:
+		}
+	}
+	return cfg
+}
+
+func loadCertificate(certPath string, keyPath string, selfSigned bool) (tls.Certificate, error) {
+	if !selfSigned {
+		return tlsutil.LoadCertificate(certPath, keyPath)
+	}
+	if certPath == "" {
+		certPath = "void-server.crt"
+	}
+	if keyPath == "" {
+		keyPath = "void-server.key"
+	}
+	return tlsutil.LoadOrCreateSelfSigned(certPath, keyPath, []string{"localhost"})
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"Void/internal/config"
+	"Void/internal/server"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"time"
+)
+
+var version = "dev"
+
+func buildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return version
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" && len(setting.Value) >= 12 {
+			return version + "+" + setting.Value[:12]
+		}
+	}
+	return version
+}
+
+func statusCommand(args []string) int {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	socket := fs.String("socket", config.DefaultAdminSocket, "Admin socket of the running server")
+	raw := fs.Bool("json", false, "Print the status as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	status, err := fetchStatus(*socket)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot reach server on %s: %v\n", *socket, err)
+		return 1
+	}
+
+	if *raw {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(status)
+		return 0
+	}
+	printStatus(status)
+	return 0
+}
+
+func drainCommand(args []string) int {
+	fs := flag.NewFlagSet("drain", flag.ContinueOnError)
+	socket := fs.String("socket", config.DefaultAdminSocket, "Admin socket of the running server")
+	cancel := fs.Bool("cancel", false, "Stop draining and accept new rooms again")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	method := http.MethodPost
+	if *cancel {
+		method = http.MethodDelete
+	}
+	status, err := adminRequest(*socket, method, server.DrainPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot reach server on %s: %v\n", *socket, err)
+		return 1
+	}
+
+	if status.Draining {
+		fmt.Printf("Draining: new rooms refused, %d rooms still open\n", status.Rooms)
+	} else {
+		fmt.Println("Serving: new rooms accepted")
+	}
+	return 0
+}
+
+func fetchStatus(socket string) (server.Status, error) {
+	return adminRequest(socket, http.MethodGet, server.StatusPath)
+}
+
+func adminRequest(socket string, method string, path string) (server.Status, error) {
+	var status server.Status
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+
+	req, err := http.NewRequest(method, "http://void-server"+path, nil)
+	if err != nil {
+		return status, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return status, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return status, fmt.Errorf("unexpected response %s", resp.Status)
+	}
+	err = json.NewDecoder(resp.Body).Decode(&status)
+	return status, err
+}
+
+func printStatus(status server.Status) {
+	state := "serving"
+	switch {
+	case !status.Listening:
+		state = "stopped"
+	case status.Draining:
+		state = "draining"
+	}
+
+	fmt.Printf("Version:      %s\n", status.Version)
+	fmt.Printf("Uptime:       %s\n", status.Uptime.Round(time.Second))
+	fmt.Printf("State:        %s\n", state)
+	fmt.Printf("Connections:  %d\n", status.Connections)
+	fmt.Printf("Rooms:        %d\n", status.Rooms)
+	fmt.Printf("Resumable:    %d\n", status.SuspendedSessions)
+
+	cfg := status.Config
+	fmt.Println("Config:")
+	fmt.Printf("  address          %s\n", cfg.Address)
+	fmt.Printf("  websocket        %s\n", orNone(cfg.WebSocketAddress))
+	fmt.Printf("  metrics          %s\n", orNone(cfg.MetricsAddress))
+	fmt.Printf("  tls              %t\n", cfg.TLS)
+	fmt.Printf("  max conns per ip %s\n", limit(cfg.MaxConnsPerIP))
+	fmt.Printf("  max rooms        %s\n", limit(cfg.MaxRooms))
+	fmt.Printf("  max room size    %s\n", limit(cfg.MaxRoomSize))
+	fmt.Printf("  send queue       %d (%s)\n", cfg.SendQueueSize, cfg.SlowConsumer)
+	fmt.Printf("  idle timeout     %s\n", cfg.IdleTimeout)
+	fmt.Printf("  resume window    %s\n", cfg.ResumeWindow)
+	fmt.Printf("  log level        %s\n", cfg.LogLevel)
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "off"
+	}
+	return value
+}
+
+func limit(value int) string {
+	if value <= 0 {
+		return "unlimited"
+	}
+	return fmt.Sprint(value)
+}
@@ -2,16 +2,24 @@ import { useState, useEffect, useRef } from "react";
 import "./App.css";
 import {
   ConnectToRoom,
+  CreateRoom,
   SendMessage,
-  GenerateRoomID,
+  GenerateInvite,
   Disconnect,
   GetMyPublicKeyFingerprint,
-  SetPeerFingerprint,
-  GetPeerFingerprint,
   GetPeerKeyFingerprint,
+  UnlockIdentity,
+  GetConnectionState,
 } from "../wailsjs/go/main/App";
+import { client } from "../wailsjs/go/models";
 import { EventsOn } from "../wailsjs/runtime/runtime";
-import { t, setLanguage, getLanguage } from "./i18n";
+import {
+  t,
+  setLanguage,
+  getLanguage,
+  errorKey,
+  identityErrorMessage,
+} from "./i18n";
 
 interface Message {
   id: string;
@@ -20,7 +28,10 @@ interface Message {
   content: string;
   timestamp: number;
   isSystem?: boolean;
-  systemType?: "join" | "leave";
+  systemType?: "join" | "leave" | "rateLimited" | "dropped" | "error";
+  retryAfterMs?: number;
+  droppedCount?: number;
+  errorCode?: string;
 }
 
 interface Peer {
@@ -35,10 +46,13 @@ function App() {
   const [username, setUsername] = useState("");
   const [password, setPassword] = useState("");
   const [joinPassword, setJoinPassword] = useState("");
+  const [passphrase, setPassphrase] = useState("");
   const [message, setMessage] = useState("");
   const [messages, setMessages] = useState<Message[]>([]);
   const [peers, setPeers] = useState<Peer[]>([]);
   const [myUserId, setMyUserId] = useState<string>("");
+  const [connectionState, setConnectionState] =
+    useState<client.StateChange | null>(null);
   const [language, setLanguageState] = useState(getLanguage());
   const [peerFingerprints, setPeerFingerprints] = useState<Map<string, string>>(
     new Map(),
@@ -100,26 +114,12 @@ function App() {
       });
     };
 
-    const peerJoinCallback = async (
+    const peerJoinCallback = (
       userId: string,
       username: string,
       fingerprint?: string,
     ) => {
       if (fingerprint) {
-        const storageKey = `fingerprint:${userId}`;
-        const savedFingerprint = localStorage.getItem(storageKey);
-
-        if (savedFingerprint && savedFingerprint !== fingerprint) {
-          console.warn(
-            `Key fingerprint mismatch for ${username} (${userId}): expected ${savedFingerprint}, got ${fingerprint}`,
-          );
-        } else if (!savedFingerprint) {
-          localStorage.setItem(storageKey, fingerprint);
-          await SetPeerFingerprint(userId, fingerprint);
-        } else {
-          await SetPeerFingerprint(userId, fingerprint);
-        }
-
         setPeerFingerprints((prev) => new Map(prev).set(userId, fingerprint));
       }
 
@@ -221,11 +221,84 @@ function App() {
       );
     };
 
-    const roomErrorCallback = (message: string) => {
-      alert(t("errors.invalidPassword"));
+    const securityEventCallback = (
+      kind: string,
+      userId: string,
+      username: string,
+      previousUsername?: string,
+    ) => {
+      console.warn(`SECURITY WARNING: ${kind} for ${username} (${userId})`);
+      if (kind === "invalidMembership") {
+        alert(`${t("security.invalidMembership")} ${username}`);
+      }
+      if (kind === "identityRenamed") {
+        alert(
+          `${t("security.identityRenamed")} ${username}\n${t("security.previousName")}: ${previousUsername}`,
+        );
+      }
+    };
+
+    const roomErrorCallback = (code: string, message: string) => {
+      alert(t(errorKey(code)));
       setConnected(false);
     };
 
+    const serverErrorCallback = (
+      code: string,
+      detail: string,
+      requestId: string,
+    ) => {
+      console.warn(`Server error ${code} (request ${requestId}): ${detail}`);
+      const timestamp = Date.now();
+      setMessages((prev) => [
+        ...prev,
+        {
+          id: `system-error-${code}-${timestamp}`,
+          userId: "",
+          username: "",
+          content: errorKey(code),
+          timestamp: timestamp,
+          isSystem: true,
+          systemType: "error",
+          errorCode: code,
+        },
+      ]);
+    };
+
+    const rateLimitedCallback = (scope: string, retryAfterMs: number) => {
+      const timestamp = Date.now();
+      setMessages((prev) => [
+        ...prev,
+        {
+          id: `system-ratelimited-${scope}-${timestamp}`,
+          userId: "",
+          username: "",
+          content: "chat.rateLimited",
+          timestamp: timestamp,
+          isSystem: true,
+          systemType: "rateLimited",
+          retryAfterMs: retryAfterMs,
+        },
+      ]);
+    };
+
+    const messagesDroppedCallback = (count: number) => {
+      const timestamp = Date.now();
+      setMessages((prev) => [
+        ...prev,
+        {
+          id: `system-dropped-${timestamp}`,
+          userId: "",
+          username: "",
+          content: "chat.messagesDropped",
+          timestamp: timestamp,
+          isSystem: true,
+          systemType: "dropped",
+          droppedCount: count,
+        },
+      ]);
+    };
+
     const myUserIdCallback = (userId: string) => {
       setMyUserId(userId);
       roomLoadedRef.current = true;
@@ -235,8 +308,13 @@ function App() {
     EventsOn("peerJoin", peerJoinCallback);
     EventsOn("peerLeft", peerLeftCallback);
     EventsOn("keyMismatch", keyMismatchCallback);
+    EventsOn("securityEvent", securityEventCallback);
     EventsOn("roomError", roomErrorCallback);
     EventsOn("myUserId", myUserIdCallback);
+    EventsOn("connectionState", setConnectionState);
+    EventsOn("rateLimited", rateLimitedCallback);
+    EventsOn("messagesDropped", messagesDroppedCallback);
+    EventsOn("serverError", serverErrorCallback);
 
     return () => {};
   }, []);
@@ -245,14 +323,27 @@ function App() {
     messagesEndRef.current?.scrollIntoView({ behavior: "smooth" });
   }, [messages]);
 
+  const unlockIdentity = async () => {
+    try {
+      await UnlockIdentity(passphrase);
+      return true;
+    } catch (error) {
+      console.error("Identity error:", error);
+      alert(identityErrorMessage(error));
+      return false;
+    }
+  };
+
   const createNewChat = async () => {
     if (!nodeUrl || !username) return;
+    if (!(await unlockIdentity())) return;
 
-    const newRoomID = await GenerateRoomID();
+    const newRoomID = await GenerateInvite();
     setRoomID(newRoomID);
 
     try {
-      await ConnectToRoom(nodeUrl, newRoomID, username, password);
+      await CreateRoom(nodeUrl, newRoomID, username, password);
+      setConnectionState(await GetConnectionState());
       setConnected(true);
     } catch (error) {
       console.error("Connection error:", error);
@@ -262,21 +353,16 @@ function App() {
 
   const joinChat = async () => {
     if (!nodeUrl || !roomID || !username) return;
+    if (!roomID.includes("#")) {
+      alert(t("errors.invalidInvite"));
+      return;
+    }
+    if (!(await unlockIdentity())) return;
 
     try {
       await ConnectToRoom(nodeUrl, roomID, username, joinPassword);
+      setConnectionState(await GetConnectionState());
       setConnected(true);
-
-      for (let i = 0; i < localStorage.length; i++) {
-        const key = localStorage.key(i);
-        if (key && key.startsWith("fingerprint:")) {
-          const userId = key.replace("fingerprint:", "");
-          const fingerprint = localStorage.getItem(key);
-          if (fingerprint) {
-            await SetPeerFingerprint(userId, fingerprint);
-          }
-        }
-      }
     } catch (error) {
       console.error("Connection error:", error);
       alert(t("errors.connectionFailed"));
@@ -291,6 +377,7 @@ function App() {
     setPeerFingerprints(new Map());
     messageIdsRef.current.clear();
     roomLoadedRef.current = false;
+    setConnectionState(null);
   };
 
   const onSendMessage = async () => {
@@ -354,6 +441,16 @@ function App() {
                 onKeyPress={(e) => e.key === "Enter" && createNewChat()}
               />
             </div>
+            <div className="input-group">
+              <label className="input-label">{t("connection.passphrase")}</label>
+              <input
+                type="password"
+                value={passphrase}
+                onChange={(e) => setPassphrase(e.target.value)}
+                placeholder={t("connection.passphrasePlaceholder")}
+                className="input-field"
+              />
+            </div>
             <div className="input-group">
               <label className="input-label">{t("connection.password")}</label>
               <input
@@ -388,6 +485,16 @@ function App() {
                 className="input-field"
               />
             </div>
+            <div className="input-group">
+              <label className="input-label">{t("connection.passphrase")}</label>
+              <input
+                type="password"
+                value={passphrase}
+                onChange={(e) => setPassphrase(e.target.value)}
+                placeholder={t("connection.passphrasePlaceholder")}
+                className="input-field"
+              />
+            </div>
             <div className="input-group">
               <input
                 type="text"
@@ -427,6 +534,16 @@ function App() {
             <span className="peers-count">
               {peers.length + 1} {t("chat.online")}
             </span>
+            {connectionState?.state === "degraded" && (
+              <span className="connection-status" title={connectionState.cause}>
+                {t("connection.reconnecting")}
+              </span>
+            )}
+            {connectionState?.state === "error" && (
+              <span className="connection-status" title={connectionState.cause}>
+                {t("connection.reconnectFailed")}
+              </span>
+            )}
             {peerFingerprints.size > 0 && (
               <div className="security-info">
                 <div className="security-text">🔒 {t("chat.encrypted")}</div>
@@ -461,6 +578,34 @@ function App() {
           ) : (
             messages.map((msg) => {
               if (msg.isSystem) {
+                if (msg.systemType === "dropped") {
+                  return (
+                    <div key={msg.id} className="message-system">
+                      <span className="system-message-text">
+                        {t("chat.messagesDropped")}: {msg.droppedCount}
+                      </span>
+                    </div>
+                  );
+                }
+                if (msg.systemType === "error") {
+                  return (
+                    <div key={msg.id} className="message-system">
+                      <span className="system-message-text">
+                        {t(errorKey(msg.errorCode ?? ""))}
+                      </span>
+                    </div>
+                  );
+                }
+                if (msg.systemType === "rateLimited") {
+                  return (
+                    <div key={msg.id} className="message-system">
+                      <span className="system-message-text">
+                        {t("chat.rateLimited")}{" "}
+                        {Math.ceil((msg.retryAfterMs ?? 0) / 1000)}s
+                      </span>
+                    </div>
+                  );
+                }
                 const systemText =
                   msg.content === "chat.userJoined"
                     ? t("chat.userJoined")
@@ -1,7 +1,7 @@
 import enTranslations from '../translations/en';
 import ruTranslations from '../translations/ru';
 
-type TranslationKeys = 
+export type TranslationKeys = 
     | 'connection.createNewChat'
     | 'connection.joinChat'
     | 'connection.nodeUrl'
@@ -10,9 +10,13 @@ type TranslationKeys =
     | 'connection.enterChatId'
     | 'connection.password'
     | 'connection.passwordPlaceholder'
+    | 'connection.passphrase'
+    | 'connection.passphrasePlaceholder'
     | 'connection.or'
     | 'connection.connect'
     | 'connection.disconnect'
+    | 'connection.reconnecting'
+    | 'connection.reconnectFailed'
     | 'chat.room'
     | 'chat.online'
     | 'chat.typeMessage'
@@ -21,12 +25,34 @@ type TranslationKeys =
     | 'chat.encrypted'
     | 'chat.userJoined'
     | 'chat.userLeft'
+    | 'chat.rateLimited'
+    | 'chat.messagesDropped'
     | 'errors.connectionFailed'
     | 'errors.sendFailed'
     | 'errors.invalidPassword'
+    | 'errors.wrongPassphrase'
+    | 'errors.emptyPassphrase'
+    | 'errors.draining'
+    | 'errors.tooManyRooms'
+    | 'errors.invalidVerifier'
+    | 'errors.roomFull'
+    | 'errors.rateLimited'
+    | 'errors.notInRoom'
+    | 'errors.invalidMessage'
+    | 'errors.malformedFrame'
+    | 'errors.roomNotFound'
+    | 'errors.roomExists'
+    | 'errors.invalidInvite'
+    | 'errors.alreadyInRoom'
+    | 'errors.unsupported'
+    | 'errors.badRequest'
+    | 'errors.unknown'
     | 'security.keyMismatch'
+    | 'security.identityRenamed'
+    | 'security.invalidMembership'
     | 'security.expected'
-    | 'security.received';
+    | 'security.received'
+    | 'security.previousName';
 
 type Translations = {
     [key: string]: any;
@@ -73,6 +99,38 @@ export const t = (key: TranslationKeys): string => {
     return value || key;
 };
 
+const errorKeys: Record<string, TranslationKeys> = {
+    draining: 'errors.draining',
+    too_many_rooms: 'errors.tooManyRooms',
+    invalid_verifier: 'errors.invalidVerifier',
+    invalid_password: 'errors.invalidPassword',
+    room_full: 'errors.roomFull',
+    rate_limited: 'errors.rateLimited',
+    not_in_room: 'errors.notInRoom',
+    invalid_message: 'errors.invalidMessage',
+    malformed_frame: 'errors.malformedFrame',
+    room_not_found: 'errors.roomNotFound',
+    room_exists: 'errors.roomExists',
+    already_in_room: 'errors.alreadyInRoom',
+    unsupported: 'errors.unsupported',
+    bad_request: 'errors.badRequest',
+};
+
+export const errorKey = (code: string): TranslationKeys => {
+    return errorKeys[code] || 'errors.unknown';
+};
+
+const identityErrorKeys: Record<string, TranslationKeys> = {
+    'wrong passphrase': 'errors.wrongPassphrase',
+    'passphrase must not be empty': 'errors.emptyPassphrase',
+};
+
+export const identityErrorMessage = (error: unknown): string => {
+    const message = String(error);
+    const key = identityErrorKeys[message];
+    return key ? t(key) : message;
+};
+
 export const getAvailableLanguages = (): string[] => {
     return Object.keys(translations);
 };
@@ -8,9 +8,13 @@ export default {
         enterChatId: "Enter Chat ID to join",
         password: "PASSWORD (optional):",
         passwordPlaceholder: "Enter room password",
+        passphrase: "IDENTITY PASSPHRASE:",
+        passphrasePlaceholder: "Unlocks your identity key",
         or: "or",
         connect: "Connect",
         disconnect: "Disconnect",
+        reconnecting: "Reconnecting...",
+        reconnectFailed: "Connection lost",
     },
     chat: {
         room: "Room",
@@ -21,16 +25,38 @@ export default {
         encrypted: "End-to-end encrypted",
         userJoined: "joined the chat",
         userLeft: "left the chat",
+        rateLimited: "Sending too fast, message not delivered. Try again in",
+        messagesDropped: "Messages lost while the connection was too slow",
     },
     errors: {
         connectionFailed: "Failed to connect",
         sendFailed: "Failed to send message",
         invalidPassword: "Invalid password",
+        wrongPassphrase: "Wrong identity passphrase",
+        emptyPassphrase: "Choose a passphrase to protect your new identity key",
+        draining: "The server is shutting down and not accepting new chats",
+        tooManyRooms: "The server has reached its chat limit",
+        invalidVerifier: "The chat password could not be set up",
+        roomFull: "This chat is full",
+        rateLimited: "Too many requests, slow down and try again",
+        notInRoom: "You are not in a chat",
+        invalidMessage: "The server rejected the message",
+        malformedFrame: "The server could not read the message",
+        roomNotFound: "No chat with this ID. Check the ID and try again",
+        roomExists: "A chat with this ID already exists",
+        alreadyInRoom: "You are already in a chat",
+        unsupported: "The server does not support this request",
+        badRequest: "The server rejected a request it did not expect",
+        invalidInvite: "This is not a full invite. Ask for the whole link, including the part after #",
+        unknown: "The server reported an error",
     },
     security: {
         keyMismatch: "Security Warning: Key fingerprint mismatch for",
+        identityRenamed: "A known identity key is now using the name",
+        invalidMembership: "Security Warning: the server announced a member who does not hold this chat's invite. Ignored:",
         expected: "Expected",
         received: "Received",
+        previousName: "Previously",
     }
 };
 
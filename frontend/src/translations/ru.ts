@@ -8,9 +8,13 @@ export default {
     enterChatId: "Введите ID чата для подключения",
     password: "ПАРОЛЬ (необязательно):",
     passwordPlaceholder: "Введите пароль комнаты",
+    passphrase: "ПАРОЛЬНАЯ ФРАЗА КЛЮЧА:",
+    passphrasePlaceholder: "Открывает ваш ключ идентичности",
     or: "или",
     connect: "Подключиться",
     disconnect: "Отключиться",
+    reconnecting: "Переподключение...",
+    reconnectFailed: "Соединение потеряно",
   },
   chat: {
     room: "Комната",
@@ -21,16 +25,39 @@ export default {
     encrypted: "Сквозное шифрование",
     userJoined: "присоединился к чату",
     userLeft: "покинул чат",
+    rateLimited: "Слишком много сообщений, сообщение не доставлено. Повторите через",
+    messagesDropped: "Сообщения потеряны из-за медленного соединения",
   },
   errors: {
     connectionFailed: "Не удалось подключиться",
     sendFailed: "Не удалось отправить сообщение",
     invalidPassword: "Неверный пароль",
+    wrongPassphrase: "Неверная парольная фраза",
+    emptyPassphrase: "Задайте парольную фразу для защиты нового ключа идентичности",
+    draining: "Сервер завершает работу и не принимает новые чаты",
+    tooManyRooms: "На сервере достигнут лимит чатов",
+    invalidVerifier: "Не удалось установить пароль чата",
+    roomFull: "Этот чат заполнен",
+    rateLimited: "Слишком много запросов, подождите и повторите",
+    notInRoom: "Вы не находитесь в чате",
+    invalidMessage: "Сервер отклонил сообщение",
+    malformedFrame: "Сервер не смог прочитать сообщение",
+    roomNotFound: "Чат с таким ID не найден. Проверьте ID и повторите",
+    roomExists: "Чат с таким ID уже существует",
+    alreadyInRoom: "Вы уже находитесь в чате",
+    unsupported: "Сервер не поддерживает этот запрос",
+    badRequest: "Сервер отклонил неожиданный запрос",
+    invalidInvite: "Это неполное приглашение. Попросите всю ссылку, включая часть после #",
+    unknown: "Сервер сообщил об ошибке",
   },
   security: {
     keyMismatch:
       "Предупреждение безопасности: Несоответствие отпечатка ключа для",
+    identityRenamed: "Известный ключ идентичности теперь использует имя",
+    invalidMembership:
+      "Предупреждение безопасности: сервер объявил участника без приглашения в этот чат. Игнорируется:",
     expected: "Ожидалось",
     received: "Получено",
+    previousName: "Ранее",
   },
 } as const;
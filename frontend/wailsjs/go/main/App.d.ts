@@ -1,18 +1,40 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
+import {client} from '../models';
+import {keyverify} from '../models';
 
 export function ConnectToRoom(arg1:string,arg2:string,arg3:string,arg4:string):Promise<string>;
 
+export function CreateRoom(arg1:string,arg2:string,arg3:string,arg4:string):Promise<string>;
+
 export function Disconnect():Promise<void>;
 
+export function ExportTrustRecords():Promise<string>;
+
+export function GenerateInvite():Promise<string>;
+
 export function GenerateRoomID():Promise<string>;
 
-export function GetMyPublicKeyFingerprint():Promise<string>;
+export function GetConnectionState():Promise<client.StateChange>;
 
-export function GetPeerFingerprint(arg1:string):Promise<string>;
+export function GetMyPublicKeyFingerprint():Promise<string>;
 
 export function GetPeerKeyFingerprint(arg1:string):Promise<string>;
 
+export function GetSafetyNumber(arg1:string):Promise<string>;
+
+export function GetVerificationPayload(arg1:string):Promise<string>;
+
+export function HasIdentity():Promise<boolean>;
+
+export function ListTrustRecords():Promise<Array<keyverify.TrustRecord>>;
+
+export function RevokePeer(arg1:string):Promise<void>;
+
 export function SendMessage(arg1:string):Promise<void>;
 
-export function SetPeerFingerprint(arg1:string,arg2:string):Promise<void>;
+export function UnlockIdentity(arg1:string):Promise<void>;
+
+export function VerifyPayload(arg1:string):Promise<string>;
+
+export function VerifyPeer(arg1:string):Promise<void>;
@@ -6,30 +6,70 @@ export function ConnectToRoom(arg1, arg2, arg3, arg4) {
   return window['go']['main']['App']['ConnectToRoom'](arg1, arg2, arg3, arg4);
 }
 
+export function CreateRoom(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['CreateRoom'](arg1, arg2, arg3, arg4);
+}
+
 export function Disconnect() {
   return window['go']['main']['App']['Disconnect']();
 }
 
+export function ExportTrustRecords() {
+  return window['go']['main']['App']['ExportTrustRecords']();
+}
+
+export function GenerateInvite() {
+  return window['go']['main']['App']['GenerateInvite']();
+}
+
 export function GenerateRoomID() {
   return window['go']['main']['App']['GenerateRoomID']();
 }
 
-export function GetMyPublicKeyFingerprint() {
-  return window['go']['main']['App']['GetMyPublicKeyFingerprint']();
+export function GetConnectionState() {
+  return window['go']['main']['App']['GetConnectionState']();
 }
 
-export function GetPeerFingerprint(arg1) {
-  return window['go']['main']['App']['GetPeerFingerprint'](arg1);
+export function GetMyPublicKeyFingerprint() {
+  return window['go']['main']['App']['GetMyPublicKeyFingerprint']();
 }
 
 export function GetPeerKeyFingerprint(arg1) {
   return window['go']['main']['App']['GetPeerKeyFingerprint'](arg1);
 }
 
+export function GetSafetyNumber(arg1) {
+  return window['go']['main']['App']['GetSafetyNumber'](arg1);
+}
+
+export function GetVerificationPayload(arg1) {
+  return window['go']['main']['App']['GetVerificationPayload'](arg1);
+}
+
+export function HasIdentity() {
+  return window['go']['main']['App']['HasIdentity']();
+}
+
+export function ListTrustRecords() {
+  return window['go']['main']['App']['ListTrustRecords']();
+}
+
+export function RevokePeer(arg1) {
+  return window['go']['main']['App']['RevokePeer'](arg1);
+}
+
 export function SendMessage(arg1) {
   return window['go']['main']['App']['SendMessage'](arg1);
 }
 
-export function SetPeerFingerprint(arg1, arg2) {
-  return window['go']['main']['App']['SetPeerFingerprint'](arg1, arg2);
+export function UnlockIdentity(arg1) {
+  return window['go']['main']['App']['UnlockIdentity'](arg1);
+}
+
+export function VerifyPayload(arg1) {
+  return window['go']['main']['App']['VerifyPayload'](arg1);
+}
+
+export function VerifyPeer(arg1) {
+  return window['go']['main']['App']['VerifyPeer'](arg1);
 }
@@ -2,109 +2,403 @@ package client
 
 import (
 	"crypto/rand"
-	"io"
-	"net"
+	"fmt"
+	mrand "math/rand/v2"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"Void/internal/crypto"
+	"Void/internal/framing"
+	"Void/internal/identity"
 	"Void/internal/keyverify"
+	"Void/internal/protocol"
+	"Void/internal/ratchet"
+	"Void/internal/roomauth"
+	"Void/internal/transport"
 	"Void/proto/chatpb"
 
 	"golang.org/x/crypto/nacl/box"
 	"google.golang.org/protobuf/proto"
 )
 
+const (
+	CapabilityRatchet    = "ratchet"
+	CapabilitySenderKeys = "sender-keys"
+
+	challengeTimeout = 10 * time.Second
+	roomRetryLimit   = 2
+
+	DefaultPingInterval = 20 * time.Second
+	missedPongs         = 3
+
+	DefaultMaxReconnectAttempts = 10
+	reconnectBaseDelay          = 500 * time.Millisecond
+	reconnectMaxDelay           = 30 * time.Second
+)
+
 type PeerInfo struct {
 	UserID   string
 	Username string
 }
 
 type ChatClient struct {
-	conn              net.Conn
-	publicKey         *[32]byte
-	privateKey        *[32]byte
-	peers             map[string][32]byte
-	peersMu           sync.RWMutex
-	knownFingerprints map[string]string
-	fingerprintsMu    sync.RWMutex
-	username          string
-	roomID            string
-	myUserID          string
-	onMessage         func(userID string, username string, content string)
-	onPeerJoin        func(userID string, username string, publicKey [32]byte)
-	onPeerLeft        func(userID string)
-	onRoomResponse    func(peers []PeerInfo)
-	onKeyMismatch     func(userID string, username string, expectedFingerprint string, receivedFingerprint string)
-	onRoomError       func(message string)
-}
-
-func NewChatClient(username string) (*ChatClient, error) {
+	conn                 transport.Conn
+	connMu               sync.Mutex
+	state                StateChange
+	stateMu              sync.Mutex
+	closed               bool
+	closing              chan struct{}
+	address              string
+	passwordKey          *roomauth.Key
+	membershipKey        roomauth.MembershipKey
+	create               bool
+	challenge            []byte
+	roomRetries          int
+	joinRequestID        string
+	resumeToken          []byte
+	serverInfo           ServerInfo
+	requiredFeatures     []string
+	shutdownNotice       *chatpb.ServerShutdown
+	maxReconnectAttempts int
+	maxFrameSize         int
+	pingInterval         time.Duration
+	publicKey            *[32]byte
+	privateKey           *[32]byte
+	nextPublicKey        *[32]byte
+	nextPrivateKey       *[32]byte
+	identity             *identity.Identity
+	peers                map[string][32]byte
+	peerIdentities       map[string][32]byte
+	sessions             map[string]*ratchet.Session
+	capabilities         map[string][]string
+	peerSenderKeys       map[string]*ratchet.SenderKeyReceiver
+	peersMu              sync.RWMutex
+	ratchetEnabled       bool
+	senderKeys           bool
+	senderKey            *ratchet.SenderKey
+	senderKeyPeers       map[string]bool
+	senderKeyMu          sync.Mutex
+	trust                *keyverify.TrustStore
+	username             string
+	roomID               string
+	myUserID             string
+	onMessage            func(userID string, username string, content string)
+	onPeerJoin           func(userID string, username string, identityKey [32]byte)
+	onPeerLeft           func(userID string, reason string)
+	onRoomResponse       func(peers []PeerInfo)
+	onKeyMismatch        func(userID string, username string, expectedFingerprint string, receivedFingerprint string)
+	onIdentityRenamed    func(userID string, username string, previousUsername string)
+	onTrustStoreError    func(err error)
+	onRoomError          func(code string, message string)
+	onUndelivered        func(messageID string, recipients []string)
+	onStateChange        func(change StateChange)
+	onRateLimited        func(scope string, retryAfter time.Duration)
+	onMessagesDropped    func(count int)
+	onServerError        func(code string, detail string, requestID string)
+	onInvalidMembership  func(userID string, username string)
+	requests             atomic.Uint64
+}
+
+func NewChatClient(username string, id *identity.Identity) (*ChatClient, error) {
 	publicKey, privateKey, err := box.GenerateKey(rand.Reader)
 	if err != nil {
 		return nil, err
 	}
 
 	return &ChatClient{
-		publicKey:         publicKey,
-		privateKey:        privateKey,
-		peers:             make(map[string][32]byte),
-		knownFingerprints: make(map[string]string),
-		username:          username,
-		onMessage:         func(string, string, string) {},
-		onPeerJoin:        func(string, string, [32]byte) {},
-		onPeerLeft:        func(string) {},
-		onRoomResponse:    func([]PeerInfo) {},
-		onKeyMismatch:     func(string, string, string, string) {},
-		onRoomError:       func(string) {},
+		publicKey:            publicKey,
+		privateKey:           privateKey,
+		identity:             id,
+		peers:                make(map[string][32]byte),
+		peerIdentities:       make(map[string][32]byte),
+		sessions:             make(map[string]*ratchet.Session),
+		capabilities:         make(map[string][]string),
+		peerSenderKeys:       make(map[string]*ratchet.SenderKeyReceiver),
+		ratchetEnabled:       true,
+		senderKeys:           true,
+		senderKeyPeers:       make(map[string]bool),
+		trust:                keyverify.NewTrustStore(),
+		maxFrameSize:         framing.DefaultMaxFrameSize,
+		pingInterval:         DefaultPingInterval,
+		closing:              make(chan struct{}),
+		state:                StateChange{State: StateIdle, Since: time.Now()},
+		maxReconnectAttempts: DefaultMaxReconnectAttempts,
+		username:             username,
+		onMessage:            func(string, string, string) {},
+		onPeerJoin:           func(string, string, [32]byte) {},
+		onPeerLeft:           func(string, string) {},
+		onRoomResponse:       func([]PeerInfo) {},
+		onKeyMismatch:        func(string, string, string, string) {},
+		onIdentityRenamed:    func(string, string, string) {},
+		onTrustStoreError:    func(error) {},
+		onRoomError:          func(string, string) {},
+		onUndelivered:        func(string, []string) {},
+		onStateChange:        func(StateChange) {},
 	}, nil
 }
 
-func (cc *ChatClient) Connect(address string, roomID string, password string) error {
-	conn, err := net.Dial("tcp", address)
+func (cc *ChatClient) Connect(address string, invite string, password string) error {
+	return cc.connect(address, invite, password, false)
+}
+
+func (cc *ChatClient) CreateRoom(address string, invite string, password string) error {
+	return cc.connect(address, invite, password, true)
+}
+
+func (cc *ChatClient) connect(address string, invite string, password string, create bool) error {
+	roomID, secret, err := roomauth.ParseInvite(invite)
 	if err != nil {
+		cc.fail(err.Error())
 		return err
 	}
-	cc.conn = conn
+
+	cc.connMu.Lock()
+	cc.address = address
+	cc.create = create
 	cc.roomID = roomID
+	cc.connMu.Unlock()
+	cc.passwordKey = roomauth.DeriveKey(roomID, password)
+	cc.membershipKey = roomauth.DeriveMembershipKey(roomID, password, secret)
+	cc.setState(StateDialing, address)
 
-	req := &chatpb.ClientMessage{
-		Payload: &chatpb.ClientMessage_JoinRoom{
-			JoinRoom: &chatpb.RoomRequest{
-				RoomId:    roomID,
-				UserId:    "",
-				Username:  cc.username,
-				PublicKey: cc.publicKey[:],
-				Password:  password,
-			},
-		},
+	conn, err := transport.Dial(address, cc.maxFrameSize)
+	if err != nil {
+		cc.fail(err.Error())
+		return err
+	}
+
+	cc.setState(StateHandshaking, "")
+	if err := cc.join(conn); err != nil {
+		cc.fail(err.Error())
+		return err
+	}
+
+	go cc.run(conn)
+	return nil
+}
+
+func (cc *ChatClient) dial() (transport.Conn, error) {
+	cc.connMu.Lock()
+	address := cc.address
+	cc.connMu.Unlock()
+
+	conn, err := transport.Dial(address, cc.maxFrameSize)
+	if err != nil {
+		return nil, err
+	}
+	if err := cc.join(conn); err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (cc *ChatClient) join(conn transport.Conn) error {
+	if err := cc.hello(conn); err != nil {
+		conn.Close()
+		return err
+	}
+	challenge, err := readChallenge(conn)
+	if err != nil {
+		conn.Close()
+		return err
 	}
+	if err := cc.nextSessionKey(); err != nil {
+		conn.Close()
+		return err
+	}
+	cc.connMu.Lock()
+	cc.challenge = challenge
+	cc.roomRetries = 0
+	create := cc.create
+	cc.connMu.Unlock()
 
-	data, err := proto.Marshal(req)
+	data, err := cc.roomRequest(create)
 	if err != nil {
+		conn.Close()
 		return err
 	}
 
-	if _, err := conn.Write(data); err != nil {
+	if err := conn.WriteFrame(data); err != nil {
+		conn.Close()
 		return err
 	}
 
-	go cc.readLoop()
+	cc.connMu.Lock()
+	defer cc.connMu.Unlock()
+	if cc.closed {
+		conn.Close()
+		return ErrClosed
+	}
+	cc.conn = conn
+	cc.shutdownNotice = nil
+	return nil
+}
+
+func (cc *ChatClient) nextSessionKey() error {
+	publicKey, privateKey, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	cc.connMu.Lock()
+	cc.nextPublicKey = publicKey
+	cc.nextPrivateKey = privateKey
+	cc.connMu.Unlock()
 	return nil
 }
 
-func (cc *ChatClient) readLoop() {
-	buf := make([]byte, 4096)
+func (cc *ChatClient) roomRequest(create bool) ([]byte, error) {
+	requestID := cc.nextRequestID()
+	cc.connMu.Lock()
+	cc.joinRequestID = requestID
+	roomID := cc.roomID
+	challenge := cc.challenge
+	resumeToken := cc.resumeToken
+	version := cc.serverInfo.Version
+	publicKey := cc.nextPublicKey
+	cc.connMu.Unlock()
+
+	capabilities := cc.ownCapabilities()
+	proof := cc.membershipKey.Sign(roomauth.Announcement{
+		RoomID:       roomID,
+		Username:     cc.username,
+		PublicKey:    publicKey[:],
+		IdentityKey:  cc.identity.PublicKey,
+		Capabilities: capabilities,
+	})
+
+	req := &chatpb.RoomRequest{
+		RoomId:           roomID,
+		UserId:           "",
+		Username:         cc.username,
+		PublicKey:        publicKey[:],
+		IdentityKey:      cc.identity.PublicKey,
+		KeySignature:     cc.identity.SignSessionKey(publicKey),
+		Capabilities:     capabilities,
+		PasswordProof:    cc.passwordKey.Prove(roomID, challenge),
+		ResumeToken:      resumeToken,
+		MembershipProof:  proof,
+	}
+
+	msg := &chatpb.ClientMessage{
+		Payload: &chatpb.ClientMessage_JoinRoom{
+			JoinRoom: req,
+		},
+		RequestId: requestID,
+	}
+	if create {
+		req.PasswordVerifier = cc.passwordKey.Verifier()
+	}
+	if create && version >= protocol.VersionCreateRoom {
+		msg.Payload = &chatpb.ClientMessage_CreateRoom{
+			CreateRoom: req,
+		}
+	}
+	return proto.Marshal(msg)
+}
+
+func (cc *ChatClient) retryRoomRequest(code chatpb.ErrorCode) bool {
+	if code != chatpb.ErrorCode_ROOM_NOT_FOUND && code != chatpb.ErrorCode_ROOM_EXISTS {
+		return false
+	}
+
+	cc.connMu.Lock()
+	retry := cc.myUserID != "" && cc.roomRetries < roomRetryLimit
+	if retry {
+		cc.roomRetries++
+	}
+	cc.connMu.Unlock()
+	if !retry {
+		return false
+	}
+
+	data, err := cc.roomRequest(code == chatpb.ErrorCode_ROOM_NOT_FOUND)
+	if err != nil {
+		return false
+	}
+	return cc.writeFrame(data) == nil
+}
+
+func (cc *ChatClient) run(conn transport.Conn) {
+	for conn != nil {
+		done := make(chan struct{})
+		go cc.heartbeat(conn, done)
+		err := cc.readLoop(conn)
+		close(done)
+		conn.Close()
+
+		conn = cc.reconnect(err)
+	}
+}
+
+func (cc *ChatClient) reconnect(cause error) transport.Conn {
+	select {
+	case <-cc.closing:
+		return nil
+	default:
+	}
+	if cc.maxReconnectAttempts <= 0 {
+		cc.fail("connection lost: " + cause.Error())
+		return nil
+	}
+
+	cc.connMu.Lock()
+	notice := cc.shutdownNotice
+	if notice != nil && notice.AlternateAddress != "" {
+		if address, err := transport.Redirect(cc.address, notice.AlternateAddress); err == nil {
+			cc.address = address
+		}
+	}
+	cc.connMu.Unlock()
+
+	delay := reconnectBaseDelay
+	wait := delay
+	if notice != nil {
+		cc.setState(StateDegraded, "server shutting down: "+notice.Reason)
+		if notice.ReconnectAfterSeconds > 0 {
+			wait = time.Duration(notice.ReconnectAfterSeconds) * time.Second
+		}
+	} else {
+		cc.setState(StateDegraded, "connection lost: "+cause.Error())
+	}
+
+	for attempt := 1; attempt <= cc.maxReconnectAttempts; attempt++ {
+		jitter := time.Duration(mrand.Int64N(int64(wait/2) + 1))
+		select {
+		case <-cc.closing:
+			return nil
+		case <-time.After(wait + jitter):
+		}
+
+		conn, err := cc.dial()
+		if err == nil {
+			return conn
+		}
+		if err == ErrClosed {
+			return nil
+		}
+		cause = err
+		cc.setState(StateDegraded, fmt.Sprintf("reconnect attempt %d/%d failed: %v", attempt, cc.maxReconnectAttempts, err))
+		delay = min(delay*2, reconnectMaxDelay)
+		wait = delay
+	}
+
+	cc.fail("reconnect failed: " + cause.Error())
+	return nil
+}
+
+func (cc *ChatClient) readLoop(conn transport.Conn) error {
 	for {
-		n, err := cc.conn.Read(buf)
+		conn.SetReadDeadline(time.Now().Add(cc.pingInterval * missedPongs))
+		frame, err := conn.ReadFrame()
 		if err != nil {
-			if err != io.EOF {
-				return
-			}
-			break
+			return err
 		}
 
 		msg := &chatpb.ServerMessage{}
-		if err := proto.Unmarshal(buf[:n], msg); err != nil {
+		if err := proto.Unmarshal(frame, msg); err != nil {
 			continue
 		}
 
@@ -117,68 +411,328 @@ func (cc *ChatClient) readLoop() {
 			cc.peerLeft(payload.PeerLeft)
 		case *chatpb.ServerMessage_RoomResponse:
 			cc.roomResponse(payload.RoomResponse)
+		case *chatpb.ServerMessage_DeliveryReport:
+			cc.deliveryReport(payload.DeliveryReport)
+		case *chatpb.ServerMessage_SenderKey:
+			cc.receiveSenderKey(payload.SenderKey)
+		case *chatpb.ServerMessage_ServerShutdown:
+			cc.connMu.Lock()
+			cc.shutdownNotice = payload.ServerShutdown
+			cc.connMu.Unlock()
+		case *chatpb.ServerMessage_RateLimited:
+			cc.rateLimited(payload.RateLimited)
+		case *chatpb.ServerMessage_MessagesDropped:
+			if cc.onMessagesDropped != nil {
+				cc.onMessagesDropped(int(payload.MessagesDropped.Count))
+			}
+		case *chatpb.ServerMessage_ServerError:
+			if cc.onServerError != nil {
+				serverError := payload.ServerError
+				cc.onServerError(errorCode(serverError.Code), serverError.Detail, serverError.RequestId)
+			}
 		}
 	}
 }
 
+func (cc *ChatClient) heartbeat(conn transport.Conn, done chan struct{}) {
+	ticker := time.NewTicker(cc.pingInterval)
+	defer ticker.Stop()
+
+	var nonce uint64
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			nonce++
+			msg := &chatpb.ClientMessage{
+				Payload: &chatpb.ClientMessage_Ping{
+					Ping: &chatpb.Ping{
+						Nonce: nonce,
+					},
+				},
+			}
+			data, _ := proto.Marshal(msg)
+			if err := conn.WriteFrame(data); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (cc *ChatClient) writeFrame(data []byte) error {
+	cc.connMu.Lock()
+	conn := cc.conn
+	shuttingDown := cc.shutdownNotice != nil
+	maxFrameSize := cc.serverInfo.MaxFrameSize
+	cc.connMu.Unlock()
+
+	if conn == nil {
+		return ErrNotConnected
+	}
+	if shuttingDown {
+		return ErrServerShuttingDown
+	}
+	if maxFrameSize > 0 && len(data) > maxFrameSize {
+		return framing.ErrFrameTooLarge
+	}
+	return conn.WriteFrame(data)
+}
+
+func leaveReason(reason chatpb.LeaveReason) string {
+	if reason == chatpb.LeaveReason_TIMEOUT {
+		return "timeout"
+	}
+	return "left"
+}
+
+func (cc *ChatClient) rateLimited(limited *chatpb.RateLimited) {
+	retryAfter := time.Duration(limited.RetryAfterMs) * time.Millisecond
+	cc.connMu.Lock()
+	join := limited.RequestId != "" && limited.RequestId == cc.joinRequestID
+	cc.connMu.Unlock()
+	if join {
+		time.AfterFunc(retryAfter, func() { cc.retryJoin(limited.RequestId) })
+	}
+
+	if cc.onRateLimited != nil {
+		cc.onRateLimited(strings.ToLower(limited.Scope.String()), retryAfter)
+	}
+}
+
+func (cc *ChatClient) retryJoin(requestID string) {
+	cc.connMu.Lock()
+	pending := !cc.closed && cc.joinRequestID == requestID
+	create := cc.create
+	cc.connMu.Unlock()
+	if !pending {
+		return
+	}
+
+	data, err := cc.roomRequest(create)
+	if err != nil {
+		return
+	}
+	cc.writeFrame(data)
+}
+
+func errorCode(code chatpb.ErrorCode) string {
+	return strings.ToLower(code.String())
+}
+
+func (cc *ChatClient) nextRequestID() string {
+	return strconv.FormatUint(cc.requests.Add(1), 10)
+}
+
 func (cc *ChatClient) roomResponse(resp *chatpb.RoomResponse) {
 	if !resp.GetSuccess() {
-		cc.onRoomError(resp.GetMessage())
+		if cc.retryRoomRequest(resp.GetCode()) {
+			return
+		}
+		cc.fail("join rejected: " + resp.GetMessage())
+		cc.onRoomError(errorCode(resp.GetCode()), resp.GetMessage())
 		return
 	}
+
+	cc.connMu.Lock()
+	previousID := cc.myUserID
 	cc.myUserID = resp.GetUserId()
-	cc.peersMu.Lock()
+	cc.resumeToken = resp.GetResumeToken()
+	cc.create = false
+	cc.joinRequestID = ""
+	publicKey, privateKey := cc.nextPublicKey, cc.nextPrivateKey
+	cc.connMu.Unlock()
+
+	resumed := previousID == resp.GetUserId()
+	if !resumed {
+		cc.resetPeers(publicKey, privateKey)
+	}
+
+	changed := previousID != "" && !resumed
+	present := make(map[string]bool, len(resp.GetPeers()))
 	peerInfos := make([]PeerInfo, 0, len(resp.GetPeers()))
 	for _, peer := range resp.GetPeers() {
-		var key [32]byte
-		copy(key[:], peer.GetPublicKey())
-		userID := peer.GetUserId()
-		cc.peers[userID] = key
-		cc.verifyPeerKey(userID, peer.GetUsername(), &key)
+		present[peer.GetUserId()] = true
+		if !cc.hasPeer(peer.GetUserId(), peer.GetPublicKey()) {
+			if !cc.addPeer(peer.GetUserId(), peer.GetUsername(), peer.GetPublicKey(), peer.GetIdentityKey(), peer.GetKeySignature(), peer.GetCapabilities(), peer.GetMembershipProof()) {
+				continue
+			}
+			changed = changed || previousID != ""
+		}
 		peerInfos = append(peerInfos, PeerInfo{
-			UserID:   userID,
+			UserID:   peer.GetUserId(),
 			Username: peer.GetUsername(),
 		})
 	}
-	cc.peersMu.Unlock()
+
+	if resumed {
+		for _, userID := range cc.peerIDs() {
+			if !present[userID] {
+				cc.removePeer(userID)
+				cc.onPeerLeft(userID, leaveReason(chatpb.LeaveReason_LEFT))
+				changed = true
+			}
+		}
+	}
+	if changed {
+		cc.rotateSenderKey()
+	}
+
+	cc.setState(StateJoined, "")
 	cc.onRoomResponse(peerInfos)
 }
 
 func (cc *ChatClient) peerJoined(peer *chatpb.PeerJoined) {
-	var key [32]byte
-	copy(key[:], peer.PublicKey)
-	userID := peer.UserId
+	if cc.hasPeer(peer.UserId, peer.PublicKey) {
+		return
+	}
+	if !cc.addPeer(peer.UserId, peer.Username, peer.PublicKey, peer.IdentityKey, peer.KeySignature, peer.Capabilities, peer.MembershipProof) {
+		return
+	}
+	cc.rotateSenderKey()
+	identityKey, _ := cc.GetPeerIdentityKey(peer.UserId)
+	cc.onPeerJoin(peer.UserId, peer.Username, identityKey)
+}
+
+func (cc *ChatClient) hasPeer(userID string, publicKey []byte) bool {
+	cc.peersMu.RLock()
+	defer cc.peersMu.RUnlock()
+	key, exists := cc.peers[userID]
+	return exists && string(key[:]) == string(publicKey)
+}
+
+func (cc *ChatClient) peerIDs() []string {
+	cc.peersMu.RLock()
+	defer cc.peersMu.RUnlock()
+	ids := make([]string, 0, len(cc.peers))
+	for userID := range cc.peers {
+		ids = append(ids, userID)
+	}
+	return ids
+}
+
+func (cc *ChatClient) resetPeers(publicKey *[32]byte, privateKey *[32]byte) {
+	cc.peersMu.Lock()
+	cc.publicKey = publicKey
+	cc.privateKey = privateKey
+	cc.peers = make(map[string][32]byte)
+	cc.peerIdentities = make(map[string][32]byte)
+	cc.sessions = make(map[string]*ratchet.Session)
+	cc.capabilities = make(map[string][]string)
+	cc.peerSenderKeys = make(map[string]*ratchet.SenderKeyReceiver)
+	cc.peersMu.Unlock()
+}
+
+func (cc *ChatClient) addPeer(userID string, username string, publicKey []byte, identityKey []byte, signature []byte, capabilities []string, membershipProof []byte) bool {
+	if !identity.VerifySessionKey(identityKey, publicKey, signature) {
+		return false
+	}
+	announcement := roomauth.Announcement{
+		RoomID:       cc.GetRoomID(),
+		Username:     username,
+		PublicKey:    publicKey,
+		IdentityKey:  identityKey,
+		Capabilities: capabilities,
+	}
+	if !cc.membershipKey.Verify(announcement, membershipProof) {
+		if cc.onInvalidMembership != nil {
+			cc.onInvalidMembership(userID, username)
+		}
+		return false
+	}
+
+	var key, idKey [32]byte
+	copy(key[:], publicKey)
+	copy(idKey[:], identityKey)
+
+	var session *ratchet.Session
+	if cc.ratchetEnabled && hasCapability(capabilities, CapabilityRatchet) {
+		var err error
+		session, err = ratchet.NewSession(cc.privateKey, cc.publicKey, &key, cc.identity.PublicKey, identityKey)
+		if err != nil {
+			return false
+		}
+	}
+
 	cc.peersMu.Lock()
 	cc.peers[userID] = key
+	cc.peerIdentities[userID] = idKey
+	cc.capabilities[userID] = capabilities
+	delete(cc.peerSenderKeys, userID)
+	if session != nil {
+		cc.sessions[userID] = session
+	} else {
+		delete(cc.sessions, userID)
+	}
 	cc.peersMu.Unlock()
-	cc.verifyPeerKey(userID, peer.Username, &key)
-	cc.onPeerJoin(userID, peer.Username, key)
+
+	cc.verifyPeerKey(userID, username, &idKey)
+	return true
 }
 
 func (cc *ChatClient) peerLeft(peer *chatpb.PeerLeft) {
+	cc.removePeer(peer.UserId)
+	cc.rotateSenderKey()
+	cc.onPeerLeft(peer.UserId, leaveReason(peer.Reason))
+}
+
+func (cc *ChatClient) removePeer(userID string) {
 	cc.peersMu.Lock()
-	delete(cc.peers, peer.UserId)
+	delete(cc.peers, userID)
+	delete(cc.peerIdentities, userID)
+	delete(cc.sessions, userID)
+	delete(cc.capabilities, userID)
+	delete(cc.peerSenderKeys, userID)
 	cc.peersMu.Unlock()
-	cc.onPeerLeft(peer.UserId)
+}
+
+func (cc *ChatClient) deliveryReport(report *chatpb.DeliveryReport) {
+	var undelivered []string
+	for _, result := range report.Results {
+		if result.Status != chatpb.DeliveryStatus_DELIVERED && result.Status != chatpb.DeliveryStatus_QUEUED {
+			undelivered = append(undelivered, result.RecipientUserId)
+		}
+	}
+	if len(undelivered) > 0 {
+		cc.onUndelivered(report.MessageId, undelivered)
+	}
 }
 
 func (cc *ChatClient) receiveMessage(msg *chatpb.ReceiveMessage) {
+	var decrypted []byte
+	var err error
+	if msg.Suite == chatpb.CipherSuite_SENDER_KEY {
+		decrypted, err = cc.decryptGroupMessage(msg.UserId, msg.EncryptedContent)
+	} else {
+		decrypted, err = cc.decryptFrom(msg.UserId, msg.Suite, msg.EncryptedContent)
+	}
+	if err == nil {
+		cc.onMessage(msg.UserId, msg.Username, string(decrypted))
+	}
+}
+
+func (cc *ChatClient) decryptFrom(userID string, suite chatpb.CipherSuite, ciphertext []byte) ([]byte, error) {
 	cc.peersMu.RLock()
-	peerKey, exists := cc.peers[msg.UserId]
+	peerKey, exists := cc.peers[userID]
+	session := cc.sessions[userID]
+	blocked := cc.isBlockedLocked(userID)
+	privateKey := cc.privateKey
 	cc.peersMu.RUnlock()
 
-	if !exists {
-		return
+	if !exists || blocked {
+		return nil, ErrUnknownPeer
 	}
 
-	messages, err := crypto.UnpackEncryptedMessages(msg.EncryptedContent)
-	if err != nil || len(messages) == 0 {
-		return
-	}
-
-	decrypted, err := crypto.DecryptMessage(messages[0], &peerKey, cc.privateKey)
-	if err == nil {
-		cc.onMessage(msg.UserId, msg.Username, string(decrypted))
+	switch suite {
+	case chatpb.CipherSuite_RATCHET:
+		if session == nil {
+			return nil, ErrUnknownPeer
+		}
+		return session.Decrypt(ciphertext)
+	case chatpb.CipherSuite_BOX:
+		return crypto.DecryptMessage(ciphertext, &peerKey, privateKey)
+	default:
+		return nil, crypto.ErrInvalidMessage
 	}
 }
 
@@ -187,20 +741,23 @@ func (cc *ChatClient) SendMessage(content string) error {
 		return nil
 	}
 
-	encryptedMessages, err := cc.encryptForAllPeers(content)
-	if err != nil || len(encryptedMessages) == 0 {
-		return err
+	if cc.canUseSenderKeys() {
+		return cc.sendGroupMessage([]byte(content))
 	}
 
-	packed := crypto.PackEncryptedMessages(encryptedMessages)
+	envelopes, err := cc.encryptForAllPeers([]byte(content))
+	if err != nil || len(envelopes) == 0 {
+		return err
+	}
 
 	msg := &chatpb.ClientMessage{
 		Payload: &chatpb.ClientMessage_SendMessage{
 			SendMessage: &chatpb.SendMessage{
-				RoomId:           cc.roomID,
-				EncryptedContent: packed,
+				RoomId:    cc.GetRoomID(),
+				Envelopes: envelopes,
 			},
 		},
+		RequestId: cc.nextRequestID(),
 	}
 
 	data, err := proto.Marshal(msg)
@@ -208,11 +765,10 @@ func (cc *ChatClient) SendMessage(content string) error {
 		return err
 	}
 
-	_, err = cc.conn.Write(data)
-	return err
+	return cc.writeFrame(data)
 }
 
-func (cc *ChatClient) encryptForAllPeers(content string) ([][]byte, error) {
+func (cc *ChatClient) encryptForAllPeers(content []byte) ([]*chatpb.Envelope, error) {
 	cc.peersMu.RLock()
 	defer cc.peersMu.RUnlock()
 
@@ -220,39 +776,95 @@ func (cc *ChatClient) encryptForAllPeers(content string) ([][]byte, error) {
 		return nil, nil
 	}
 
-	type peerKeyPair struct {
-		userID string
-		key    [32]byte
+	envelopes := make([]*chatpb.Envelope, 0, len(cc.peers))
+	for userID := range cc.peers {
+		if cc.isBlockedLocked(userID) {
+			continue
+		}
+		envelope, err := cc.encryptForLocked(userID, content)
+		if err != nil {
+			continue
+		}
+		envelopes = append(envelopes, envelope)
+	}
+
+	return envelopes, nil
+}
+
+func (cc *ChatClient) encryptForLocked(userID string, content []byte) (*chatpb.Envelope, error) {
+	if session, exists := cc.sessions[userID]; exists {
+		encrypted, err := session.Encrypt(content)
+		if err != nil {
+			return nil, err
+		}
+		return &chatpb.Envelope{
+			RecipientUserId: userID,
+			Ciphertext:      encrypted,
+			Suite:           chatpb.CipherSuite_RATCHET,
+		}, nil
 	}
 
-	peers := make([]peerKeyPair, 0, len(cc.peers))
-	for userID, key := range cc.peers {
-		peers = append(peers, peerKeyPair{userID: userID, key: key})
+	key := cc.peers[userID]
+	encrypted, err := crypto.EncryptMessage(content, &key, cc.privateKey)
+	if err != nil {
+		return nil, err
 	}
+	return &chatpb.Envelope{
+		RecipientUserId: userID,
+		Ciphertext:      encrypted,
+		Suite:           chatpb.CipherSuite_BOX,
+	}, nil
+}
 
-	contentBytes := []byte(content)
-	encryptedMessages := make([][]byte, 0, len(peers))
+func (cc *ChatClient) ownCapabilities() []string {
+	var capabilities []string
+	if cc.ratchetEnabled {
+		capabilities = append(capabilities, CapabilityRatchet)
+	}
+	if cc.senderKeys && cc.serverSupports(protocol.FeatureSenderKeys) {
+		capabilities = append(capabilities, CapabilitySenderKeys)
+	}
+	return capabilities
+}
 
-	for _, peer := range peers {
-		encrypted, err := crypto.EncryptMessage(contentBytes, &peer.key, cc.privateKey)
-		if err != nil {
-			continue
+func hasCapability(capabilities []string, capability string) bool {
+	for _, c := range capabilities {
+		if c == capability {
+			return true
 		}
-		encryptedMessages = append(encryptedMessages, encrypted)
 	}
+	return false
+}
+
+func (cc *ChatClient) SetRatchetEnabled(enabled bool) {
+	cc.ratchetEnabled = enabled
+}
+
+func (cc *ChatClient) SetSenderKeysEnabled(enabled bool) {
+	cc.senderKeys = enabled
+}
+
+func (cc *ChatClient) SetPingInterval(interval time.Duration) {
+	cc.pingInterval = interval
+}
+
+func (cc *ChatClient) SetMaxReconnectAttempts(attempts int) {
+	cc.maxReconnectAttempts = attempts
+}
 
-	return encryptedMessages, nil
+func (cc *ChatClient) SetMaxFrameSize(size int) {
+	cc.maxFrameSize = size
 }
 
 func (cc *ChatClient) SetOnMessage(fn func(userID string, username string, content string)) {
 	cc.onMessage = fn
 }
 
-func (cc *ChatClient) SetOnPeerJoin(fn func(userID string, username string, publicKey [32]byte)) {
+func (cc *ChatClient) SetOnPeerJoin(fn func(userID string, username string, identityKey [32]byte)) {
 	cc.onPeerJoin = fn
 }
 
-func (cc *ChatClient) SetOnPeerLeft(fn func(userID string)) {
+func (cc *ChatClient) SetOnPeerLeft(fn func(userID string, reason string)) {
 	cc.onPeerLeft = fn
 }
 
@@ -264,50 +876,86 @@ func (cc *ChatClient) SetOnKeyMismatch(fn func(userID string, username string, e
 	cc.onKeyMismatch = fn
 }
 
-func (cc *ChatClient) SetOnRoomError(fn func(message string)) {
+func (cc *ChatClient) SetOnIdentityRenamed(fn func(userID string, username string, previousUsername string)) {
+	cc.onIdentityRenamed = fn
+}
+
+func (cc *ChatClient) SetOnTrustStoreError(fn func(err error)) {
+	cc.onTrustStoreError = fn
+}
+
+func (cc *ChatClient) SetOnRoomError(fn func(code string, message string)) {
 	cc.onRoomError = fn
 }
 
-func (cc *ChatClient) verifyPeerKey(userID string, username string, publicKey *[32]byte) {
-	currentFingerprint := keyverify.ComputeKeyFingerprint(publicKey)
+func (cc *ChatClient) SetOnUndelivered(fn func(messageID string, recipients []string)) {
+	cc.onUndelivered = fn
+}
+
+func (cc *ChatClient) SetOnRateLimited(fn func(scope string, retryAfter time.Duration)) {
+	cc.onRateLimited = fn
+}
 
-	cc.fingerprintsMu.RLock()
-	knownFingerprint, exists := cc.knownFingerprints[userID]
-	cc.fingerprintsMu.RUnlock()
+func (cc *ChatClient) SetOnServerError(fn func(code string, detail string, requestID string)) {
+	cc.onServerError = fn
+}
 
-	if exists && knownFingerprint != currentFingerprint {
-		cc.onKeyMismatch(userID, username, knownFingerprint, currentFingerprint)
-		return
-	}
+func (cc *ChatClient) SetOnInvalidMembership(fn func(userID string, username string)) {
+	cc.onInvalidMembership = fn
+}
 
-	if !exists {
-		cc.fingerprintsMu.Lock()
-		cc.knownFingerprints[userID] = currentFingerprint
-		cc.fingerprintsMu.Unlock()
+func (cc *ChatClient) SetOnMessagesDropped(fn func(count int)) {
+	cc.onMessagesDropped = fn
+}
+
+func (cc *ChatClient) verifyPeerKey(userID string, username string, identityKey *[32]byte) {
+	fingerprint := keyverify.ComputeKeyFingerprint(identityKey)
+	record, previousUsername, err := cc.trust.Observe(fingerprint, username)
+	if err != nil {
+		cc.onTrustStoreError(err)
+	}
+	if record.Level == keyverify.TrustChanged && len(record.PreviousFingerprints) > 0 {
+		cc.onKeyMismatch(userID, username, record.PreviousFingerprints[0], fingerprint)
+	}
+	if previousUsername != "" {
+		cc.onIdentityRenamed(userID, username, previousUsername)
 	}
 }
 
-func (cc *ChatClient) SetKnownFingerprint(userID string, fingerprint string) {
-	cc.fingerprintsMu.Lock()
-	cc.knownFingerprints[userID] = fingerprint
-	cc.fingerprintsMu.Unlock()
+func (cc *ChatClient) isBlockedLocked(userID string) bool {
+	identityKey, exists := cc.peerIdentities[userID]
+	if !exists {
+		return true
+	}
+	return cc.trust.Level(keyverify.ComputeKeyFingerprint(&identityKey)) == keyverify.TrustChanged
 }
 
-func (cc *ChatClient) GetKnownFingerprint(userID string) (string, bool) {
-	cc.fingerprintsMu.RLock()
-	defer cc.fingerprintsMu.RUnlock()
-	fingerprint, exists := cc.knownFingerprints[userID]
-	return fingerprint, exists
+func (cc *ChatClient) SetTrustStore(trust *keyverify.TrustStore) {
+	cc.trust = trust
 }
 
 func (cc *ChatClient) GetPublicKey() [32]byte {
+	cc.peersMu.RLock()
+	defer cc.peersMu.RUnlock()
 	return *cc.publicKey
 }
 
+func (cc *ChatClient) GetIdentityKey() [32]byte {
+	return cc.identity.PublicKeyArray()
+}
+
 func (cc *ChatClient) GetUserID() string {
+	cc.connMu.Lock()
+	defer cc.connMu.Unlock()
 	return cc.myUserID
 }
 
+func (cc *ChatClient) GetRoomID() string {
+	cc.connMu.Lock()
+	defer cc.connMu.Unlock()
+	return cc.roomID
+}
+
 func (cc *ChatClient) GetUsername() string {
 	return cc.username
 }
@@ -319,18 +967,64 @@ func (cc *ChatClient) GetPeerKey(userID string) ([32]byte, bool) {
 	return key, exists
 }
 
+func (cc *ChatClient) GetPeerIdentityKey(userID string) ([32]byte, bool) {
+	cc.peersMu.RLock()
+	defer cc.peersMu.RUnlock()
+	key, exists := cc.peerIdentities[userID]
+	return key, exists
+}
+
 func (cc *ChatClient) Close() error {
-	if cc.conn != nil {
-		msg := &chatpb.ClientMessage{
-			Payload: &chatpb.ClientMessage_LeaveRoom{
-				LeaveRoom: &chatpb.RoomRequest{
-					RoomId: cc.roomID,
-				},
+	conn := cc.shutdown()
+	cc.setState(StateClosed, "closed by user")
+	if conn == nil {
+		return nil
+	}
+
+	msg := &chatpb.ClientMessage{
+		Payload: &chatpb.ClientMessage_LeaveRoom{
+			LeaveRoom: &chatpb.RoomRequest{
+				RoomId: cc.GetRoomID(),
 			},
-		}
-		data, _ := proto.Marshal(msg)
-		cc.conn.Write(data)
-		return cc.conn.Close()
+		},
 	}
-	return nil
+	data, _ := proto.Marshal(msg)
+	conn.WriteFrame(data)
+	return conn.Close()
 }
+
+func (cc *ChatClient) fail(cause string) {
+	if conn := cc.shutdown(); conn != nil {
+		conn.Close()
+	}
+	cc.setState(StateError, cause)
+}
+
+func (cc *ChatClient) shutdown() transport.Conn {
+	cc.connMu.Lock()
+	defer cc.connMu.Unlock()
+	if cc.closed {
+		return nil
+	}
+	cc.closed = true
+	close(cc.closing)
+	return cc.conn
+}
+
+type ClientError string
+
+func (e ClientError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrUnknownPeer        = ClientError("unknown or blocked peer")
+	ErrMissingChallenge   = ClientError("server did not send an auth challenge")
+	ErrMissingServerHello = ClientError("server did not send a hello")
+	ErrUnsupportedVersion = ClientError("unsupported protocol version")
+	ErrUnsupportedFeature = ClientError("unsupported protocol feature")
+	ErrHelloRejected      = ClientError("server rejected the hello")
+	ErrNotConnected       = ClientError("not connected")
+	ErrClosed             = ClientError("client closed")
+	ErrServerShuttingDown = ClientError("server is shutting down")
+)
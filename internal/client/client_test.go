@@ -0,0 +1,464 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"Void/internal/identity"
+	"Void/internal/keyverify"
+	"Void/internal/protocol"
+	"Void/internal/roomauth"
+	"Void/internal/server"
+	"Void/proto/chatpb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+const testInvite = "room#AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+
+type cutProxy struct {
+	listener net.Listener
+	target   string
+	mu       sync.Mutex
+	conns    []net.Conn
+}
+
+func startServer(t *testing.T) string {
+	t.Helper()
+	return startServerWith(t, server.DefaultOptions())
+}
+
+func startServerWith(t *testing.T, opts server.Options) string {
+	t.Helper()
+	opts.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := server.New(opts)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.Serve(ctx, listener)
+	t.Cleanup(cancel)
+	return listener.Addr().String()
+}
+
+func startProxy(t *testing.T, target string) *cutProxy {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &cutProxy{listener: listener, target: target}
+	t.Cleanup(func() {
+		listener.Close()
+		p.cut()
+	})
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			p.mu.Lock()
+			target := p.target
+			p.mu.Unlock()
+			upstream, err := net.Dial("tcp", target)
+			if err != nil {
+				conn.Close()
+				continue
+			}
+			p.mu.Lock()
+			p.conns = append(p.conns, conn, upstream)
+			p.mu.Unlock()
+			go io.Copy(upstream, conn)
+			go io.Copy(conn, upstream)
+		}
+	}()
+	return p
+}
+
+func (p *cutProxy) retarget(target string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.target = target
+}
+
+func (p *cutProxy) cut() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, conn := range p.conns {
+		conn.Close()
+	}
+	p.conns = nil
+}
+
+func newTestClient(t *testing.T, username string) (*ChatClient, <-chan StateChange) {
+	t.Helper()
+	id, err := identity.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cc, err := NewChatClient(username, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	states := make(chan StateChange, 64)
+	cc.SetOnStateChange(func(change StateChange) {
+		select {
+		case states <- change:
+		default:
+		}
+	})
+	t.Cleanup(func() { cc.Close() })
+	return cc, states
+}
+
+func waitForState(t *testing.T, states <-chan StateChange, state ConnectionState) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case change := <-states:
+			if change.State == state {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s", state)
+		}
+	}
+}
+
+func readConcurrently(cc *ChatClient) func() {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			cc.GetUserID()
+			cc.GetRoomID()
+			cc.GetServerInfo()
+			cc.GetState()
+			cc.SendMessage("hello")
+		}
+	}()
+	return func() {
+		close(stop)
+		<-done
+	}
+}
+
+func TestReconnectWhileReading(t *testing.T) {
+	proxy := startProxy(t, startServer(t))
+	cc, states := newTestClient(t, "alice")
+
+	stop := readConcurrently(cc)
+	defer stop()
+
+	if err := cc.CreateRoom(proxy.listener.Addr().String(), testInvite, ""); err != nil {
+		t.Fatal(err)
+	}
+	waitForState(t, states, StateJoined)
+	userID := cc.GetUserID()
+	publicKey := cc.GetPublicKey()
+	if userID == "" || cc.GetRoomID() != "room" {
+		t.Fatalf("joined as %q in %q", userID, cc.GetRoomID())
+	}
+
+	proxy.cut()
+	waitForState(t, states, StateDegraded)
+	waitForState(t, states, StateJoined)
+	if cc.GetUserID() != userID {
+		t.Fatalf("resumed as %q, want %q", cc.GetUserID(), userID)
+	}
+	if cc.GetPublicKey() != publicKey {
+		t.Fatal("resume replaced the session key")
+	}
+
+	bob, bobStates := newTestClient(t, "bob")
+	if err := bob.Connect(proxy.listener.Addr().String(), testInvite, ""); err != nil {
+		t.Fatal(err)
+	}
+	waitForState(t, bobStates, StateJoined)
+	if key, _ := bob.GetPeerKey(userID); key != publicKey {
+		t.Fatal("a later peer was told a key the resumed member does not hold")
+	}
+}
+
+func TestCloseDuringConnect(t *testing.T) {
+	address := startServer(t)
+	cc, _ := newTestClient(t, "alice")
+
+	result := make(chan error, 1)
+	go func() {
+		result <- cc.CreateRoom(address, testInvite, "")
+	}()
+	cc.Close()
+
+	if err := <-result; err != nil && err != ErrClosed {
+		t.Fatalf("CreateRoom: %v", err)
+	}
+	if state := cc.GetState().State; state != StateClosed {
+		t.Fatalf("state %s, want %s", state, StateClosed)
+	}
+}
+
+func announce(t *testing.T, cc *ChatClient) *chatpb.Peer {
+	t.Helper()
+	if err := cc.nextSessionKey(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := cc.roomRequest(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := &chatpb.ClientMessage{}
+	if err := proto.Unmarshal(data, msg); err != nil {
+		t.Fatal(err)
+	}
+	req := msg.GetJoinRoom()
+	return &chatpb.Peer{
+		Username:        req.Username,
+		PublicKey:       req.PublicKey,
+		IdentityKey:     req.IdentityKey,
+		KeySignature:    req.KeySignature,
+		Capabilities:    req.Capabilities,
+		MembershipProof: req.MembershipProof,
+	}
+}
+
+func inRoom(t *testing.T, username string) *ChatClient {
+	t.Helper()
+	cc, _ := newTestClient(t, username)
+	roomID, secret, err := roomauth.ParseInvite(testInvite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cc.roomID = roomID
+	cc.passwordKey = roomauth.DeriveKey(roomID, "")
+	cc.membershipKey = roomauth.DeriveMembershipKey(roomID, "", secret)
+	return cc
+}
+
+func TestRejoinUsesFreshSessionKeys(t *testing.T) {
+	alice := inRoom(t, "alice")
+	bob := inRoom(t, "bob")
+
+	var previous [][]byte
+	for round := range 2 {
+		aliceID, bobID := fmt.Sprintf("alice-%d", round), fmt.Sprintf("bob-%d", round)
+		alicePeer, bobPeer := announce(t, alice), announce(t, bob)
+		alicePeer.UserId, bobPeer.UserId = aliceID, bobID
+		alice.roomResponse(&chatpb.RoomResponse{Success: true, UserId: aliceID, Peers: []*chatpb.Peer{bobPeer}})
+		bob.roomResponse(&chatpb.RoomResponse{Success: true, UserId: bobID, Peers: []*chatpb.Peer{alicePeer}})
+
+		var ciphertexts [][]byte
+		for _, pair := range []struct {
+			from, to     *ChatClient
+			fromID, toID string
+		}{{alice, bob, aliceID, bobID}, {bob, alice, bobID, aliceID}} {
+			envelopes, err := pair.from.encryptForAllPeers([]byte("same plaintext"))
+			if err != nil || len(envelopes) != 1 || envelopes[0].Suite != chatpb.CipherSuite_RATCHET {
+				t.Fatalf("round %d: envelopes %v, %v", round, envelopes, err)
+			}
+			if _, err := pair.to.decryptFrom(pair.fromID, envelopes[0].Suite, envelopes[0].Ciphertext); err != nil {
+				t.Fatalf("round %d: %v", round, err)
+			}
+			ciphertexts = append(ciphertexts, envelopes[0].Ciphertext)
+		}
+
+		for i := range previous {
+			if bytes.Equal(previous[i], ciphertexts[i]) {
+				t.Fatalf("rejoin repeated ciphertext %d", i)
+			}
+		}
+		previous = ciphertexts
+	}
+}
+
+func TestPeerKeySwapBlocked(t *testing.T) {
+	alice := inRoom(t, "alice")
+	bob := inRoom(t, "bob")
+	impostor := inRoom(t, "bob")
+
+	var mismatches [][2]string
+	alice.SetOnKeyMismatch(func(userID string, username string, expected string, received string) {
+		mismatches = append(mismatches, [2]string{expected, received})
+	})
+
+	alice.nextSessionKey()
+	bobPeer := announce(t, bob)
+	bobPeer.UserId = "bob-1"
+	alice.roomResponse(&chatpb.RoomResponse{Success: true, UserId: "alice", Peers: []*chatpb.Peer{bobPeer}})
+	alice.peerLeft(&chatpb.PeerLeft{UserId: "bob-1"})
+
+	swapped := announce(t, impostor)
+	alice.peerJoined(&chatpb.PeerJoined{
+		UserId:          "bob-2",
+		Username:        swapped.Username,
+		PublicKey:       swapped.PublicKey,
+		IdentityKey:     swapped.IdentityKey,
+		KeySignature:    swapped.KeySignature,
+		Capabilities:    swapped.Capabilities,
+		MembershipProof: swapped.MembershipProof,
+	})
+
+	bobKey, impostorKey := bob.GetIdentityKey(), impostor.GetIdentityKey()
+	want := [2]string{keyverify.ComputeKeyFingerprint(&bobKey), keyverify.ComputeKeyFingerprint(&impostorKey)}
+	if len(mismatches) != 1 || mismatches[0] != want {
+		t.Fatalf("mismatches %v, want [%v]", mismatches, want)
+	}
+	if envelopes, _ := alice.encryptForAllPeers([]byte("hi")); len(envelopes) != 0 {
+		t.Fatal("encrypted to a swapped key")
+	}
+	if _, err := alice.decryptFrom("bob-2", chatpb.CipherSuite_RATCHET, nil); err != ErrUnknownPeer {
+		t.Fatalf("decrypt from a swapped key: %v", err)
+	}
+
+	if err := alice.trust.Verify(want[1]); err != nil {
+		t.Fatal(err)
+	}
+	if envelopes, _ := alice.encryptForAllPeers([]byte("hi")); len(envelopes) != 1 {
+		t.Fatal("verified key still blocked")
+	}
+}
+
+func TestThrottledJoinRetries(t *testing.T) {
+	opts := server.DefaultOptions()
+	opts.IPRate = server.RateLimit{PerSecond: 5, Burst: 1}
+	address := startServerWith(t, opts)
+
+	alice, aliceStates := newTestClient(t, "alice")
+	if err := alice.CreateRoom(address, testInvite, ""); err != nil {
+		t.Fatal(err)
+	}
+	waitForState(t, aliceStates, StateJoined)
+
+	bob, bobStates := newTestClient(t, "bob")
+	limited := make(chan time.Duration, 1)
+	bob.SetOnRateLimited(func(scope string, retryAfter time.Duration) {
+		select {
+		case limited <- retryAfter:
+		default:
+		}
+	})
+	if err := bob.Connect(address, testInvite, ""); err != nil {
+		t.Fatal(err)
+	}
+	waitForState(t, bobStates, StateJoined)
+	select {
+	case <-limited:
+	default:
+		t.Fatal("join was not throttled")
+	}
+}
+
+func TestVerifierOnlyOnCreate(t *testing.T) {
+	cc := inRoom(t, "alice")
+	cc.passwordKey = roomauth.DeriveKey(cc.roomID, "pw")
+	cc.challenge = make([]byte, roomauth.ChallengeSize)
+	cc.nextSessionKey()
+
+	for _, tt := range []struct {
+		version  uint32
+		create   bool
+		verifier bool
+	}{
+		{protocol.Version, false, false},
+		{protocol.Version, true, true},
+		{protocol.VersionCreateRoom - 1, false, false},
+		{protocol.VersionCreateRoom - 1, true, true},
+	} {
+		cc.serverInfo.Version = tt.version
+		data, err := cc.roomRequest(tt.create)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg := &chatpb.ClientMessage{}
+		if err := proto.Unmarshal(data, msg); err != nil {
+			t.Fatal(err)
+		}
+		req := msg.GetJoinRoom()
+		if req == nil {
+			req = msg.GetCreateRoom()
+		}
+		if got := req.PasswordVerifier != nil; got != tt.verifier {
+			t.Errorf("version %d, create %v: verifier sent = %v", tt.version, tt.create, got)
+		}
+		if !roomauth.Verify(cc.passwordKey.Verifier(), cc.roomID, cc.challenge, req.PasswordProof) {
+			t.Errorf("version %d, create %v: no valid password proof", tt.version, tt.create)
+		}
+	}
+}
+
+func expectRoomError(t *testing.T, cc *ChatClient, states <-chan StateChange, join func() error, want string) {
+	t.Helper()
+	codes := make(chan string, 1)
+	cc.SetOnRoomError(func(code string, message string) {
+		codes <- code
+	})
+	if err := join(); err != nil {
+		t.Fatal(err)
+	}
+	waitForState(t, states, StateError)
+	if code := <-codes; code != want {
+		t.Fatalf("room error %q, want %q", code, want)
+	}
+}
+
+func TestJoinRequiresExistingRoom(t *testing.T) {
+	address := startServer(t)
+
+	bob, bobStates := newTestClient(t, "bob")
+	expectRoomError(t, bob, bobStates, func() error { return bob.Connect(address, testInvite, "") }, "room_not_found")
+
+	alice, aliceStates := newTestClient(t, "alice")
+	if err := alice.CreateRoom(address, testInvite, ""); err != nil {
+		t.Fatal(err)
+	}
+	waitForState(t, aliceStates, StateJoined)
+
+	carol, carolStates := newTestClient(t, "carol")
+	expectRoomError(t, carol, carolStates, func() error { return carol.CreateRoom(address, testInvite, "") }, "room_exists")
+}
+
+func TestRejoinRecreatesRoom(t *testing.T) {
+	proxy := startProxy(t, startServer(t))
+	cc, states := newTestClient(t, "alice")
+	roomErrors := make(chan string, 1)
+	cc.SetOnRoomError(func(code string, message string) {
+		roomErrors <- code
+	})
+
+	if err := cc.CreateRoom(proxy.listener.Addr().String(), testInvite, ""); err != nil {
+		t.Fatal(err)
+	}
+	waitForState(t, states, StateJoined)
+	userID := cc.GetUserID()
+
+	proxy.retarget(startServer(t))
+	proxy.cut()
+	waitForState(t, states, StateDegraded)
+	waitForState(t, states, StateJoined)
+	if cc.GetUserID() == userID {
+		t.Fatal("kept the user ID on a server that never saw it")
+	}
+	select {
+	case code := <-roomErrors:
+		t.Fatalf("room error %q", code)
+	default:
+	}
+}
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"Void/internal/protocol"
+	"Void/internal/roomauth"
+	"Void/internal/transport"
+	"Void/proto/chatpb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+type ServerInfo struct {
+	Version      uint32
+	Features     []string
+	MaxFrameSize int
+	MaxRoomSize  int
+}
+
+func (cc *ChatClient) hello(conn transport.Conn) error {
+	msg, err := readServerMessage(conn)
+	if err != nil {
+		return err
+	}
+	hello := msg.GetServerHello()
+	if hello == nil {
+		return ErrMissingServerHello
+	}
+
+	version, ok := protocol.Choose(hello.MinVersion, hello.MaxVersion)
+	if !ok {
+		return fmt.Errorf("%w: server speaks versions %d to %d, client speaks %d to %d",
+			ErrUnsupportedVersion, hello.MinVersion, hello.MaxVersion, protocol.MinVersion, protocol.Version)
+	}
+	required := append([]string{protocol.FeatureMembership}, cc.requiredFeatures...)
+	if missing := protocol.Missing(hello.Features, required); len(missing) > 0 {
+		return fmt.Errorf("%w: server does not support %s", ErrUnsupportedFeature, strings.Join(missing, ", "))
+	}
+
+	info := ServerInfo{
+		Version:      version,
+		Features:     protocol.Common(cc.ownFeatures(), hello.Features),
+		MaxFrameSize: int(hello.MaxFrameSize),
+		MaxRoomSize:  int(hello.MaxRoomSize),
+	}
+	cc.connMu.Lock()
+	cc.serverInfo = info
+	cc.connMu.Unlock()
+
+	reply := &chatpb.ClientMessage{
+		Payload: &chatpb.ClientMessage_ClientHello{
+			ClientHello: &chatpb.ClientHello{
+				ProtocolVersion:  info.Version,
+				Features:         info.Features,
+				RequiredFeatures: required,
+			},
+		},
+	}
+	data, err := proto.Marshal(reply)
+	if err != nil {
+		return err
+	}
+	return conn.WriteFrame(data)
+}
+
+func readChallenge(conn transport.Conn) ([]byte, error) {
+	msg, err := readServerMessage(conn)
+	if err != nil {
+		return nil, err
+	}
+	if rejected := msg.GetHelloRejected(); rejected != nil {
+		return nil, helloError(rejected)
+	}
+	challenge := msg.GetAuthChallenge()
+	if challenge == nil || len(challenge.Nonce) != roomauth.ChallengeSize {
+		return nil, ErrMissingChallenge
+	}
+	return challenge.Nonce, nil
+}
+
+func readServerMessage(conn transport.Conn) (*chatpb.ServerMessage, error) {
+	conn.SetReadDeadline(time.Now().Add(challengeTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+
+	frame, err := conn.ReadFrame()
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &chatpb.ServerMessage{}
+	if err := proto.Unmarshal(frame, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func helloError(rejected *chatpb.HelloRejected) error {
+	switch rejected.Code {
+	case chatpb.HelloError_UNSUPPORTED_VERSION:
+		return fmt.Errorf("%w: %s", ErrUnsupportedVersion, rejected.Detail)
+	case chatpb.HelloError_UNSUPPORTED_FEATURE:
+		return fmt.Errorf("%w: %s", ErrUnsupportedFeature, rejected.Detail)
+	default:
+		return fmt.Errorf("%w: %s", ErrHelloRejected, rejected.Detail)
+	}
+}
+
+func (cc *ChatClient) ownFeatures() []string {
+	features := []string{
+		protocol.FeatureFraming,
+		protocol.FeatureResume,
+		protocol.FeatureRateLimit,
+		protocol.FeatureDropNotice,
+		protocol.FeatureMembership,
+	}
+	if cc.ratchetEnabled {
+		features = append(features, protocol.FeatureRatchet)
+	}
+	if cc.senderKeys {
+		features = append(features, protocol.FeatureSenderKeys)
+	}
+	return features
+}
+
+func (cc *ChatClient) serverSupports(feature string) bool {
+	cc.connMu.Lock()
+	defer cc.connMu.Unlock()
+	return protocol.Has(cc.serverInfo.Features, feature)
+}
+
+func (cc *ChatClient) SetRequiredFeatures(features ...string) {
+	cc.requiredFeatures = features
+}
+
+func (cc *ChatClient) GetServerInfo() ServerInfo {
+	cc.connMu.Lock()
+	defer cc.connMu.Unlock()
+	return cc.serverInfo
+}
@@ -0,0 +1,179 @@
+package client
+
+import (
+	"crypto/ed25519"
+
+	"Void/internal/identity"
+	"Void/internal/protocol"
+	"Void/internal/ratchet"
+	"Void/proto/chatpb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func (cc *ChatClient) canUseSenderKeys() bool {
+	if !cc.senderKeys || !cc.serverSupports(protocol.FeatureSenderKeys) {
+		return false
+	}
+
+	cc.peersMu.RLock()
+	defer cc.peersMu.RUnlock()
+
+	eligible := 0
+	for userID := range cc.peers {
+		if cc.isBlockedLocked(userID) {
+			continue
+		}
+		if !hasCapability(cc.capabilities[userID], CapabilitySenderKeys) {
+			return false
+		}
+		eligible++
+	}
+	return eligible > 0
+}
+
+func (cc *ChatClient) rotateSenderKey() {
+	cc.senderKeyMu.Lock()
+	cc.senderKey = nil
+	cc.senderKeyPeers = make(map[string]bool)
+	cc.senderKeyMu.Unlock()
+}
+
+func (cc *ChatClient) sendGroupMessage(content []byte) error {
+	cc.senderKeyMu.Lock()
+	defer cc.senderKeyMu.Unlock()
+
+	if err := cc.distributeSenderKeyLocked(); err != nil {
+		return err
+	}
+
+	ciphertext, err := cc.senderKey.Encrypt(content)
+	if err != nil {
+		return err
+	}
+	signed := append(ciphertext, cc.identity.SignGroupMessage(ciphertext)...)
+
+	msg := &chatpb.ClientMessage{
+		Payload: &chatpb.ClientMessage_SendMessage{
+			SendMessage: &chatpb.SendMessage{
+				RoomId:          cc.GetRoomID(),
+				GroupCiphertext: signed,
+			},
+		},
+		RequestId: cc.nextRequestID(),
+	}
+
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return cc.writeFrame(data)
+}
+
+func (cc *ChatClient) distributeSenderKeyLocked() error {
+	if cc.senderKey == nil {
+		senderKey, err := ratchet.NewSenderKey()
+		if err != nil {
+			return err
+		}
+		cc.senderKey = senderKey
+		cc.senderKeyPeers = make(map[string]bool)
+	}
+
+	keyID, chainKey, iteration := cc.senderKey.Material()
+	material, err := proto.Marshal(&chatpb.SenderKeyMaterial{
+		KeyId:     keyID,
+		ChainKey:  chainKey[:],
+		Iteration: iteration,
+	})
+	if err != nil {
+		return err
+	}
+
+	cc.peersMu.RLock()
+	var envelopes []*chatpb.Envelope
+	var recipients []string
+	for userID := range cc.peers {
+		if cc.senderKeyPeers[userID] || cc.isBlockedLocked(userID) {
+			continue
+		}
+		envelope, err := cc.encryptForLocked(userID, material)
+		if err != nil {
+			continue
+		}
+		envelopes = append(envelopes, envelope)
+		recipients = append(recipients, userID)
+	}
+	cc.peersMu.RUnlock()
+
+	if len(envelopes) == 0 {
+		return nil
+	}
+
+	msg := &chatpb.ClientMessage{
+		Payload: &chatpb.ClientMessage_SenderKeyDistribution{
+			SenderKeyDistribution: &chatpb.SenderKeyDistribution{
+				RoomId:    cc.GetRoomID(),
+				Envelopes: envelopes,
+			},
+		},
+		RequestId: cc.nextRequestID(),
+	}
+
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if err := cc.writeFrame(data); err != nil {
+		return err
+	}
+
+	for _, userID := range recipients {
+		cc.senderKeyPeers[userID] = true
+	}
+	return nil
+}
+
+func (cc *ChatClient) receiveSenderKey(msg *chatpb.SenderKeyMessage) {
+	plaintext, err := cc.decryptFrom(msg.UserId, msg.Suite, msg.EncryptedContent)
+	if err != nil {
+		return
+	}
+
+	material := &chatpb.SenderKeyMaterial{}
+	if err := proto.Unmarshal(plaintext, material); err != nil || len(material.ChainKey) != 32 {
+		return
+	}
+
+	var chainKey [32]byte
+	copy(chainKey[:], material.ChainKey)
+	receiver := ratchet.NewSenderKeyReceiver(material.KeyId, chainKey, material.Iteration)
+
+	cc.peersMu.Lock()
+	if _, exists := cc.peers[msg.UserId]; exists {
+		cc.peerSenderKeys[msg.UserId] = receiver
+	}
+	cc.peersMu.Unlock()
+}
+
+func (cc *ChatClient) decryptGroupMessage(userID string, signed []byte) ([]byte, error) {
+	if len(signed) < ed25519.SignatureSize {
+		return nil, ratchet.ErrInvalidMessage
+	}
+	ciphertext := signed[:len(signed)-ed25519.SignatureSize]
+	signature := signed[len(signed)-ed25519.SignatureSize:]
+
+	cc.peersMu.RLock()
+	receiver := cc.peerSenderKeys[userID]
+	identityKey, exists := cc.peerIdentities[userID]
+	blocked := cc.isBlockedLocked(userID)
+	cc.peersMu.RUnlock()
+
+	if !exists || blocked || receiver == nil {
+		return nil, ErrUnknownPeer
+	}
+	if !identity.VerifyGroupMessage(identityKey[:], ciphertext, signature) {
+		return nil, ratchet.ErrDecryptionFailed
+	}
+	return receiver.Decrypt(ciphertext)
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"time"
+)
+
+type ConnectionState string
+
+const (
+	StateIdle        ConnectionState = "idle"
+	StateDialing     ConnectionState = "dialing"
+	StateHandshaking ConnectionState = "handshaking"
+	StateJoined      ConnectionState = "joined"
+	StateDegraded    ConnectionState = "degraded"
+	StateClosed      ConnectionState = "closed"
+	StateError       ConnectionState = "error"
+)
+
+type StateChange struct {
+	State ConnectionState `json:"state"`
+	Cause string          `json:"cause"`
+	Since time.Time       `json:"since"`
+}
+
+var transitions = map[ConnectionState][]ConnectionState{
+	StateIdle:        {StateDialing, StateClosed},
+	StateDialing:     {StateHandshaking, StateError, StateClosed},
+	StateHandshaking: {StateJoined, StateDegraded, StateError, StateClosed},
+	StateJoined:      {StateDegraded, StateError, StateClosed},
+	StateDegraded:    {StateDegraded, StateJoined, StateError, StateClosed},
+	StateError:       {StateClosed},
+}
+
+func canTransition(from ConnectionState, to ConnectionState) bool {
+	for _, state := range transitions[from] {
+		if state == to {
+			return true
+		}
+	}
+	return false
+}
+
+func (cc *ChatClient) setState(state ConnectionState, cause string) bool {
+	cc.stateMu.Lock()
+	defer cc.stateMu.Unlock()
+
+	if !canTransition(cc.state.State, state) {
+		return false
+	}
+	cc.state = StateChange{
+		State: state,
+		Cause: cause,
+		Since: time.Now(),
+	}
+	cc.onStateChange(cc.state)
+	return true
+}
+
+func (cc *ChatClient) GetState() StateChange {
+	cc.stateMu.Lock()
+	defer cc.stateMu.Unlock()
+	return cc.state
+}
+
+func (cc *ChatClient) SetOnStateChange(fn func(change StateChange)) {
+	cc.onStateChange = fn
+}
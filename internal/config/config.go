@@ -0,0 +1,294 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"reflect"
+	"time"
+
+	"Void/internal/logging"
+	"Void/internal/server"
+	"Void/internal/transport"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	Listen   Listen   `yaml:"listen"`
+	TLS      TLS      `yaml:"tls"`
+	Limits   Limits   `yaml:"limits"`
+	Timeouts Timeouts `yaml:"timeouts"`
+	Shutdown Shutdown `yaml:"shutdown"`
+	Log      Log      `yaml:"log"`
+}
+
+type Listen struct {
+	Address        string   `yaml:"address"`
+	WebSocket      string   `yaml:"websocket"`
+	Metrics        string   `yaml:"metrics"`
+	AdminSocket    string   `yaml:"admin_socket"`
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+type TLS struct {
+	Cert       string `yaml:"cert"`
+	Key        string `yaml:"key"`
+	SelfSigned bool   `yaml:"self_signed"`
+}
+
+type Limits struct {
+	MaxFrameSize  int          `yaml:"max_frame_size"`
+	SendBuffer    int          `yaml:"send_buffer"`
+	MaxConnsPerIP int          `yaml:"max_connections_per_ip"`
+	MaxRooms      int          `yaml:"max_rooms"`
+	MaxRoomSize   int          `yaml:"max_room_size"`
+	Rate          RateLimits   `yaml:"rate"`
+	SlowConsumer  SlowConsumer `yaml:"slow_consumer"`
+}
+
+type SlowConsumer struct {
+	Policy   string `yaml:"policy"`
+	MaxDrops int    `yaml:"max_drops"`
+}
+
+type RateLimits struct {
+	Connection Rate `yaml:"connection"`
+	IP         Rate `yaml:"ip"`
+	Room       Rate `yaml:"room"`
+}
+
+type Rate struct {
+	PerSecond float64 `yaml:"per_second"`
+	Burst     int     `yaml:"burst"`
+}
+
+type Timeouts struct {
+	Handshake    time.Duration `yaml:"handshake"`
+	Idle         time.Duration `yaml:"idle"`
+	Write        time.Duration `yaml:"write"`
+	ResumeWindow time.Duration `yaml:"resume_window"`
+}
+
+type Shutdown struct {
+	Drain            time.Duration `yaml:"drain"`
+	Timeout          time.Duration `yaml:"timeout"`
+	ReconnectAfter   time.Duration `yaml:"reconnect_after"`
+	AlternateAddress string        `yaml:"alternate_address"`
+	Grace            time.Duration `yaml:"grace"`
+}
+
+type Log struct {
+	Level     string        `yaml:"level"`
+	Format    string        `yaml:"format"`
+	File      string        `yaml:"file"`
+	MaxSizeMB int           `yaml:"max_size_mb"`
+	MaxFiles  int           `yaml:"max_files"`
+	MaxAge    time.Duration `yaml:"max_age"`
+	Redact    Redact        `yaml:"redact"`
+}
+
+type Redact struct {
+	IP   string `yaml:"ip"`
+	Room string `yaml:"room"`
+}
+
+const (
+	DefaultShutdownTimeout = 10 * time.Second
+	DefaultAdminSocket     = "void-server.sock"
+	DefaultLogMaxSizeMB    = 100
+	DefaultLogMaxFiles     = 7
+	DefaultLogMaxAge       = 7 * 24 * time.Hour
+)
+
+func Default() *Config {
+	opts := server.DefaultOptions()
+	return &Config{
+		Listen: Listen{
+			Address:     opts.Address,
+			AdminSocket: DefaultAdminSocket,
+		},
+		Limits: Limits{
+			MaxFrameSize:  opts.MaxFrameSize,
+			SendBuffer:    opts.SendQueueSize,
+			MaxConnsPerIP: opts.MaxConnsPerIP,
+			SlowConsumer: SlowConsumer{
+				Policy:   string(opts.SlowConsumer),
+				MaxDrops: opts.MaxDrops,
+			},
+			Rate: RateLimits{
+				Connection: Rate(opts.ConnectionRate),
+				IP:         Rate(opts.IPRate),
+				Room:       Rate(opts.RoomRate),
+			},
+		},
+		Timeouts: Timeouts{
+			Handshake:    opts.HandshakeTimeout,
+			Idle:         opts.IdleTimeout,
+			Write:        opts.WriteTimeout,
+			ResumeWindow: opts.ResumeWindow,
+		},
+		Shutdown: Shutdown{
+			Timeout: DefaultShutdownTimeout,
+			Grace:   opts.ShutdownGrace,
+		},
+		Log: Log{
+			Level:     "info",
+			Format:    logging.FormatText,
+			MaxSizeMB: DefaultLogMaxSizeMB,
+			MaxFiles:  DefaultLogMaxFiles,
+			MaxAge:    DefaultLogMaxAge,
+			Redact: Redact{
+				IP:   string(opts.Redaction.IP),
+				Room: string(opts.Redaction.Room),
+			},
+		},
+	}
+}
+
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+func Parse(data []byte) (*Config, error) {
+	cfg := Default()
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(validAddress(c.Listen.Address), "listen.address: %q is not a host:port address", c.Listen.Address)
+	check(c.Listen.WebSocket == "" || validAddress(c.Listen.WebSocket), "listen.websocket: %q is not a host:port address", c.Listen.WebSocket)
+	check(c.Listen.Metrics == "" || validAddress(c.Listen.Metrics), "listen.metrics: %q is not a host:port address", c.Listen.Metrics)
+	if _, err := transport.ParseNetworks(c.Listen.TrustedProxies); err != nil {
+		errs = append(errs, fmt.Errorf("listen.trusted_proxies: %w", err))
+	}
+
+	check((c.TLS.Cert == "") == (c.TLS.Key == "") || c.TLS.SelfSigned, "tls: cert and key must be set together")
+
+	check(c.Limits.MaxFrameSize > 0, "limits.max_frame_size must be positive")
+	check(c.Limits.SendBuffer > 0, "limits.send_buffer must be positive")
+	check(c.Limits.MaxConnsPerIP >= 0, "limits.max_connections_per_ip must not be negative")
+	check(c.Limits.MaxRooms >= 0, "limits.max_rooms must not be negative")
+	check(c.Limits.MaxRoomSize >= 0, "limits.max_room_size must not be negative")
+	check(server.SlowConsumerPolicy(c.Limits.SlowConsumer.Policy).Valid(), "limits.slow_consumer.policy: %q is not one of drop-newest, drop-oldest, disconnect", c.Limits.SlowConsumer.Policy)
+	check(c.Limits.SlowConsumer.MaxDrops > 0, "limits.slow_consumer.max_drops must be positive")
+	checkRate := func(name string, rate Rate) {
+		check(rate.PerSecond >= 0, "limits.rate.%s.per_second must not be negative", name)
+		check(rate.PerSecond == 0 || rate.Burst > 0, "limits.rate.%s.burst must be positive", name)
+	}
+	checkRate("connection", c.Limits.Rate.Connection)
+	checkRate("ip", c.Limits.Rate.IP)
+	checkRate("room", c.Limits.Rate.Room)
+
+	check(c.Timeouts.Handshake > 0, "timeouts.handshake must be positive")
+	check(c.Timeouts.Idle > 0, "timeouts.idle must be positive")
+	check(c.Timeouts.Write > 0, "timeouts.write must be positive")
+	check(c.Timeouts.ResumeWindow >= 0, "timeouts.resume_window must not be negative")
+
+	check(c.Shutdown.Drain >= 0, "shutdown.drain must not be negative")
+	check(c.Shutdown.Timeout > 0, "shutdown.timeout must be positive")
+	check(c.Shutdown.ReconnectAfter >= 0, "shutdown.reconnect_after must not be negative")
+	check(c.Shutdown.Grace > 0, "shutdown.grace must be positive")
+	check(c.Shutdown.Grace < c.Shutdown.Timeout, "shutdown.grace must be shorter than shutdown.timeout")
+
+	_, err := c.LogLevel()
+	check(err == nil, "log.level: %q is not one of debug, info, warn, error", c.Log.Level)
+	check(logging.ValidFormat(c.Log.Format), "log.format: %q is not one of text, json", c.Log.Format)
+	check(c.Log.MaxSizeMB >= 0, "log.max_size_mb must not be negative")
+	check(c.Log.MaxFiles >= 0, "log.max_files must not be negative")
+	check(c.Log.MaxAge >= 0, "log.max_age must not be negative")
+	check(logging.IPMode(c.Log.Redact.IP).Valid(), "log.redact.ip: %q is not one of truncate, full, omit", c.Log.Redact.IP)
+	check(logging.RoomMode(c.Log.Redact.Room).Valid(), "log.redact.room: %q is not one of hash, full, omit", c.Log.Redact.Room)
+
+	return errors.Join(errs...)
+}
+
+func (c *Config) LogLevel() (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(c.Log.Level))
+	return level, err
+}
+
+func (c *Config) ServerOptions() server.Options {
+	level, _ := c.LogLevel()
+	resumeWindow := c.Timeouts.ResumeWindow
+	if resumeWindow == 0 {
+		resumeWindow = -1
+	}
+
+	return server.Options{
+		Address:          c.Listen.Address,
+		WebSocketAddress: c.Listen.WebSocket,
+		MetricsAddress:   c.Listen.Metrics,
+		AdminSocket:      c.Listen.AdminSocket,
+		TrustedProxies:   c.Listen.TrustedProxies,
+		MaxFrameSize:     c.Limits.MaxFrameSize,
+		SendQueueSize:    c.Limits.SendBuffer,
+		SlowConsumer:     server.SlowConsumerPolicy(c.Limits.SlowConsumer.Policy),
+		MaxDrops:         c.Limits.SlowConsumer.MaxDrops,
+		MaxConnsPerIP:    c.Limits.MaxConnsPerIP,
+		MaxRooms:         c.Limits.MaxRooms,
+		MaxRoomSize:      c.Limits.MaxRoomSize,
+		ConnectionRate:   server.RateLimit(c.Limits.Rate.Connection),
+		IPRate:           server.RateLimit(c.Limits.Rate.IP),
+		RoomRate:         server.RateLimit(c.Limits.Rate.Room),
+		HandshakeTimeout: c.Timeouts.Handshake,
+		IdleTimeout:      c.Timeouts.Idle,
+		WriteTimeout:     c.Timeouts.Write,
+		ResumeWindow:     resumeWindow,
+		ReconnectAfter:   c.Shutdown.ReconnectAfter,
+		AlternateAddress: c.Shutdown.AlternateAddress,
+		ShutdownGrace:    c.Shutdown.Grace,
+		LogLevel:         level,
+		Redaction: logging.Redaction{
+			IP:   logging.IPMode(c.Log.Redact.IP),
+			Room: logging.RoomMode(c.Log.Redact.Room),
+		},
+	}
+}
+
+func (c *Config) RestartRequired(next *Config) []string {
+	var fields []string
+	current, updated := c.Listen, next.Listen
+	current.TrustedProxies, updated.TrustedProxies = nil, nil
+	if !reflect.DeepEqual(current, updated) {
+		fields = append(fields, "listen")
+	}
+	if c.TLS != next.TLS {
+		fields = append(fields, "tls")
+	}
+	if c.Limits.MaxFrameSize != next.Limits.MaxFrameSize {
+		fields = append(fields, "limits.max_frame_size")
+	}
+	return fields
+}
+
+func validAddress(address string) bool {
+	_, _, err := net.SplitHostPort(address)
+	return err == nil
+}
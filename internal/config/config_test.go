@@ -0,0 +1,156 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDefaultValid(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadExample(t *testing.T) {
+	if _, err := Load("../../void-server.example.yaml"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	cfg, err := Parse([]byte(`
+listen:
+  address: ":9000"
+  trusted_proxies: ["10.0.0.0/8", "127.0.0.1"]
+timeouts:
+  idle: 90s
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Listen.Address != ":9000" || cfg.Timeouts.Idle != 90*time.Second || len(cfg.Listen.TrustedProxies) != 2 {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+	if cfg.Timeouts.Handshake != Default().Timeouts.Handshake {
+		t.Fatal("unset field lost its default")
+	}
+
+	if _, err := Parse(nil); err != nil {
+		t.Fatalf("empty file: %v", err)
+	}
+}
+
+func TestParseUnknownField(t *testing.T) {
+	_, err := Parse([]byte("limits:\n  max_frame: 1024\n"))
+	if err == nil || !strings.Contains(err.Error(), "max_frame") {
+		t.Fatalf("err = %v, want unknown field error", err)
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	tests := []struct {
+		field  string
+		change func(*Config)
+	}{
+		{"listen.address", func(c *Config) { c.Listen.Address = "9000" }},
+		{"listen.websocket", func(c *Config) { c.Listen.WebSocket = "localhost" }},
+		{"listen.metrics", func(c *Config) { c.Listen.Metrics = "metrics" }},
+		{"listen.trusted_proxies", func(c *Config) { c.Listen.TrustedProxies = []string{"proxy"} }},
+		{"tls", func(c *Config) { c.TLS.Cert = "cert.pem" }},
+		{"limits.max_frame_size", func(c *Config) { c.Limits.MaxFrameSize = 0 }},
+		{"limits.send_buffer", func(c *Config) { c.Limits.SendBuffer = -1 }},
+		{"limits.max_connections_per_ip", func(c *Config) { c.Limits.MaxConnsPerIP = -1 }},
+		{"limits.max_rooms", func(c *Config) { c.Limits.MaxRooms = -1 }},
+		{"limits.max_room_size", func(c *Config) { c.Limits.MaxRoomSize = -1 }},
+		{"limits.slow_consumer.policy", func(c *Config) { c.Limits.SlowConsumer.Policy = "block" }},
+		{"limits.slow_consumer.max_drops", func(c *Config) { c.Limits.SlowConsumer.MaxDrops = 0 }},
+		{"limits.rate.connection.per_second", func(c *Config) { c.Limits.Rate.Connection.PerSecond = -1 }},
+		{"limits.rate.ip.burst", func(c *Config) { c.Limits.Rate.IP = Rate{PerSecond: 1} }},
+		{"limits.rate.room.burst", func(c *Config) { c.Limits.Rate.Room = Rate{PerSecond: 1, Burst: -1} }},
+		{"timeouts.handshake", func(c *Config) { c.Timeouts.Handshake = 0 }},
+		{"timeouts.idle", func(c *Config) { c.Timeouts.Idle = -time.Second }},
+		{"timeouts.write", func(c *Config) { c.Timeouts.Write = 0 }},
+		{"timeouts.resume_window", func(c *Config) { c.Timeouts.ResumeWindow = -time.Second }},
+		{"shutdown.drain", func(c *Config) { c.Shutdown.Drain = -time.Second }},
+		{"shutdown.timeout", func(c *Config) { c.Shutdown.Timeout = 0 }},
+		{"shutdown.reconnect_after", func(c *Config) { c.Shutdown.ReconnectAfter = -time.Second }},
+		{"shutdown.grace must be positive", func(c *Config) { c.Shutdown.Grace = 0 }},
+		{"shutdown.grace must be shorter", func(c *Config) { c.Shutdown.Grace = c.Shutdown.Timeout }},
+		{"log.level", func(c *Config) { c.Log.Level = "verbose" }},
+		{"log.format", func(c *Config) { c.Log.Format = "xml" }},
+		{"log.max_size_mb", func(c *Config) { c.Log.MaxSizeMB = -1 }},
+		{"log.max_files", func(c *Config) { c.Log.MaxFiles = -1 }},
+		{"log.max_age", func(c *Config) { c.Log.MaxAge = -time.Hour }},
+		{"log.redact.ip", func(c *Config) { c.Log.Redact.IP = "mask" }},
+		{"log.redact.room", func(c *Config) { c.Log.Redact.Room = "mask" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			cfg := Default()
+			tt.change(cfg)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.field) {
+				t.Fatalf("err = %v, want an error about %s", err, tt.field)
+			}
+		})
+	}
+}
+
+func TestValidateReportsAllErrors(t *testing.T) {
+	cfg := Default()
+	cfg.Limits.MaxFrameSize = 0
+	cfg.Timeouts.Idle = 0
+	cfg.Log.Format = "xml"
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate succeeded")
+	}
+	for _, field := range []string{"limits.max_frame_size", "timeouts.idle", "log.format"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("%v does not mention %s", err, field)
+		}
+	}
+}
+
+func TestRestartRequired(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(*Config)
+		want   []string
+	}{
+		{"nothing", func(c *Config) {}, nil},
+		{"reloadable", func(c *Config) {
+			c.Limits.MaxRooms = 10
+			c.Timeouts.Idle = time.Minute
+			c.Log.Level = "debug"
+		}, nil},
+		{"trusted proxies", func(c *Config) { c.Listen.TrustedProxies = []string{"10.0.0.1"} }, nil},
+		{"listen", func(c *Config) { c.Listen.WebSocket = ":8080" }, []string{"listen"}},
+		{"tls", func(c *Config) { c.TLS.SelfSigned = true }, []string{"tls"}},
+		{"frame size", func(c *Config) { c.Limits.MaxFrameSize *= 2 }, []string{"limits.max_frame_size"}},
+		{"several", func(c *Config) {
+			c.Listen.Address = ":1"
+			c.TLS.SelfSigned = true
+		}, []string{"listen", "tls"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := Default()
+			tt.change(next)
+			if got := Default().RestartRequired(next); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("RestartRequired = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerOptionsResumeWindow(t *testing.T) {
+	cfg := Default()
+	cfg.Timeouts.ResumeWindow = 0
+	if opts := cfg.ServerOptions(); opts.ResumeWindow >= 0 {
+		t.Fatalf("ResumeWindow = %v, want resume disabled", opts.ResumeWindow)
+	}
+}
@@ -2,7 +2,6 @@ package crypto
 
 import (
 	"crypto/rand"
-	"encoding/binary"
 	"io"
 
 	"golang.org/x/crypto/nacl/box"
@@ -44,36 +43,6 @@ func DecryptMessage(encrypted []byte, senderPublicKey *[32]byte, recipientPrivat
 	return decrypted, nil
 }
 
-func PackEncryptedMessages(messages [][]byte) []byte {
-	if len(messages) == 0 {
-		return nil
-	}
-	var result []byte
-	for _, msg := range messages {
-		sizeBuf := make([]byte, 4)
-		binary.BigEndian.PutUint32(sizeBuf, uint32(len(msg)))
-		result = append(result, sizeBuf...)
-		result = append(result, msg...)
-	}
-	return result
-}
-
-func UnpackEncryptedMessages(data []byte) ([][]byte, error) {
-	var messages [][]byte
-	for len(data) >= 4 {
-		size := binary.BigEndian.Uint32(data[:4])
-		data = data[4:]
-
-		if size > maxMessageSize || len(data) < int(size) || int(size) < minMessageSize {
-			return nil, ErrInvalidMessage
-		}
-
-		messages = append(messages, data[:size])
-		data = data[size:]
-	}
-	return messages, nil
-}
-
 type EncryptionError string
 
 func (e EncryptionError) Error() string {
@@ -0,0 +1,95 @@
+package framing
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+)
+
+const (
+	HeaderSize          = 4
+	DefaultMaxFrameSize = 1 << 20
+)
+
+type Reader struct {
+	r            *bufio.Reader
+	maxFrameSize int
+	header       [HeaderSize]byte
+}
+
+func NewReader(r io.Reader, maxFrameSize int) *Reader {
+	if maxFrameSize <= 0 {
+		maxFrameSize = DefaultMaxFrameSize
+	}
+	return &Reader{
+		r:            bufio.NewReader(r),
+		maxFrameSize: maxFrameSize,
+	}
+}
+
+func (fr *Reader) ReadFrame() ([]byte, error) {
+	if _, err := io.ReadFull(fr.r, fr.header[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, ErrFrameTruncated
+		}
+		return nil, err
+	}
+
+	size := binary.BigEndian.Uint32(fr.header[:])
+	if uint64(size) > uint64(fr.maxFrameSize) {
+		return nil, ErrFrameTooLarge
+	}
+
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(fr.r, frame); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, ErrFrameTruncated
+		}
+		return nil, err
+	}
+	return frame, nil
+}
+
+type Writer struct {
+	w            io.Writer
+	maxFrameSize int
+	mu           sync.Mutex
+}
+
+func NewWriter(w io.Writer, maxFrameSize int) *Writer {
+	if maxFrameSize <= 0 {
+		maxFrameSize = DefaultMaxFrameSize
+	}
+	return &Writer{
+		w:            w,
+		maxFrameSize: maxFrameSize,
+	}
+}
+
+func (fw *Writer) WriteFrame(data []byte) error {
+	if len(data) > fw.maxFrameSize {
+		return ErrFrameTooLarge
+	}
+
+	buf := make([]byte, HeaderSize+len(data))
+	binary.BigEndian.PutUint32(buf, uint32(len(data)))
+	copy(buf[HeaderSize:], data)
+
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	_, err := fw.w.Write(buf)
+	return err
+}
+
+type FrameError string
+
+func (e FrameError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrFrameTooLarge  = FrameError("frame exceeds maximum size")
+	ErrFrameTruncated = FrameError("truncated frame")
+)
@@ -0,0 +1,129 @@
+package framing
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+)
+
+type chunkedReader struct {
+	data   []byte
+	chunks []byte
+	next   int
+}
+
+func (r *chunkedReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	size := 1
+	if len(r.chunks) > 0 {
+		size = int(r.chunks[r.next%len(r.chunks)]) + 1
+		r.next++
+	}
+	size = min(size, len(p), len(r.data))
+	n := copy(p, r.data[:size])
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func splitFrames(data []byte, lengths []byte) [][]byte {
+	frames := [][]byte{}
+	for i := 0; len(data) > 0; i++ {
+		size := len(data)
+		if len(lengths) > 0 {
+			size = min(int(lengths[i%len(lengths)]), len(data))
+		}
+		frames = append(frames, data[:size])
+		data = data[size:]
+	}
+	return frames
+}
+
+func FuzzReader(f *testing.F) {
+	f.Add([]byte("hello world"), []byte{3}, []byte{0})
+	f.Add([]byte{}, []byte{}, []byte{})
+	f.Add(bytes.Repeat([]byte{0xaa}, 600), []byte{255, 0, 17}, []byte{1, 200, 2})
+	f.Add([]byte("abc"), []byte{0, 0, 1}, []byte{255})
+
+	f.Fuzz(func(t *testing.T, data []byte, lengths []byte, chunks []byte) {
+		frames := splitFrames(data, lengths)
+
+		var stream bytes.Buffer
+		w := NewWriter(&stream, 256)
+		for _, frame := range frames {
+			if err := w.WriteFrame(frame); err != nil {
+				t.Fatalf("WriteFrame(%d bytes): %v", len(frame), err)
+			}
+		}
+
+		r := NewReader(&chunkedReader{data: stream.Bytes(), chunks: chunks}, 256)
+		for i, want := range frames {
+			got, err := r.ReadFrame()
+			if err != nil {
+				t.Fatalf("frame %d: %v", i, err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Fatalf("frame %d = %x, want %x", i, got, want)
+			}
+		}
+		if _, err := r.ReadFrame(); err != io.EOF {
+			t.Fatalf("after last frame: got %v, want io.EOF", err)
+		}
+	})
+}
+
+func header(size uint32) []byte {
+	h := make([]byte, HeaderSize)
+	binary.BigEndian.PutUint32(h, size)
+	return h
+}
+
+func TestReaderErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		want  error
+	}{
+		{"empty stream", nil, io.EOF},
+		{"partial header", []byte{0, 0}, ErrFrameTruncated},
+		{"partial body", append(header(10), "short"...), ErrFrameTruncated},
+		{"header only", header(4), ErrFrameTruncated},
+		{"over limit", append(header(17), make([]byte, 17)...), ErrFrameTooLarge},
+		{"maximum header", header(0xffffffff), ErrFrameTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReader(bytes.NewReader(tt.input), 16)
+			if _, err := r.ReadFrame(); !errors.Is(err, tt.want) {
+				t.Fatalf("ReadFrame() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReaderAtLimit(t *testing.T) {
+	frame := bytes.Repeat([]byte{7}, 16)
+	r := NewReader(bytes.NewReader(append(header(16), frame...)), 16)
+	got, err := r.ReadFrame()
+	if err != nil {
+		t.Fatalf("ReadFrame() error = %v", err)
+	}
+	if !bytes.Equal(got, frame) {
+		t.Fatalf("ReadFrame() = %x, want %x", got, frame)
+	}
+}
+
+func TestWriterTooLarge(t *testing.T) {
+	var stream bytes.Buffer
+	w := NewWriter(&stream, 16)
+	if err := w.WriteFrame(make([]byte, 17)); !errors.Is(err, ErrFrameTooLarge) {
+		t.Fatalf("WriteFrame() error = %v, want %v", err, ErrFrameTooLarge)
+	}
+	if stream.Len() != 0 {
+		t.Fatalf("oversized frame wrote %d bytes", stream.Len())
+	}
+}
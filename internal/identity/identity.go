@@ -0,0 +1,65 @@
+package identity
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+)
+
+const (
+	sessionKeyContext   = "void-session-key-v1"
+	groupMessageContext = "void-group-message-v1"
+)
+
+type Identity struct {
+	PublicKey  ed25519.PublicKey
+	PrivateKey ed25519.PrivateKey
+}
+
+func Generate() (*Identity, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &Identity{
+		PublicKey:  publicKey,
+		PrivateKey: privateKey,
+	}, nil
+}
+
+func (id *Identity) PublicKeyArray() [32]byte {
+	var key [32]byte
+	copy(key[:], id.PublicKey)
+	return key
+}
+
+func (id *Identity) SignSessionKey(sessionKey *[32]byte) []byte {
+	return ed25519.Sign(id.PrivateKey, sessionKeyMessage(sessionKey[:]))
+}
+
+func VerifySessionKey(identityKey []byte, sessionKey []byte, signature []byte) bool {
+	if len(identityKey) != ed25519.PublicKeySize || len(sessionKey) != 32 {
+		return false
+	}
+	return ed25519.Verify(identityKey, sessionKeyMessage(sessionKey), signature)
+}
+
+func (id *Identity) SignGroupMessage(message []byte) []byte {
+	return ed25519.Sign(id.PrivateKey, withContext(groupMessageContext, message))
+}
+
+func VerifyGroupMessage(identityKey []byte, message []byte, signature []byte) bool {
+	if len(identityKey) != ed25519.PublicKeySize {
+		return false
+	}
+	return ed25519.Verify(identityKey, withContext(groupMessageContext, message), signature)
+}
+
+func sessionKeyMessage(sessionKey []byte) []byte {
+	return withContext(sessionKeyContext, sessionKey)
+}
+
+func withContext(context string, data []byte) []byte {
+	msg := make([]byte, 0, len(context)+len(data))
+	msg = append(msg, context...)
+	return append(msg, data...)
+}
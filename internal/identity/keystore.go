@@ -0,0 +1,162 @@
+package identity
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+const (
+	keystoreVersion = 1
+	keystoreFile    = "identity.json"
+
+	argonTime    = 3
+	argonMemory  = 64 * 1024
+	argonThreads = 4
+
+	maxArgonTime   = 16
+	maxArgonMemory = 1024 * 1024
+)
+
+type keystore struct {
+	Version    int    `json:"version"`
+	PublicKey  []byte `json:"public_key"`
+	Salt       []byte `json:"salt"`
+	Time       uint32 `json:"time"`
+	Memory     uint32 `json:"memory"`
+	Threads    uint8  `json:"threads"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "Void", keystoreFile), nil
+}
+
+func Exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func LoadOrCreate(path string, passphrase string) (*Identity, error) {
+	id, err := Load(path, passphrase)
+	if err == nil {
+		return id, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	id, err = Generate()
+	if err != nil {
+		return nil, err
+	}
+	if err := Save(path, passphrase, id); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+func Load(path string, passphrase string) (*Identity, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var ks keystore
+	if err := json.Unmarshal(data, &ks); err != nil {
+		return nil, ErrInvalidKeystore
+	}
+	if ks.Version != keystoreVersion || len(ks.Nonce) != 24 || len(ks.Salt) == 0 || ks.Time == 0 || ks.Time > maxArgonTime || ks.Threads == 0 || ks.Memory > maxArgonMemory {
+		return nil, ErrInvalidKeystore
+	}
+
+	key := deriveKey(passphrase, ks.Salt, ks.Time, ks.Memory, ks.Threads)
+	var nonce [24]byte
+	copy(nonce[:], ks.Nonce)
+
+	seed, ok := secretbox.Open(nil, ks.Ciphertext, &nonce, &key)
+	if !ok {
+		return nil, ErrWrongPassphrase
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, ErrInvalidKeystore
+	}
+
+	privateKey := ed25519.NewKeyFromSeed(seed)
+	return &Identity{
+		PublicKey:  privateKey.Public().(ed25519.PublicKey),
+		PrivateKey: privateKey,
+	}, nil
+}
+
+func Save(path string, passphrase string, id *Identity) error {
+	if passphrase == "" {
+		return ErrEmptyPassphrase
+	}
+
+	salt := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return err
+	}
+	var nonce [24]byte
+	if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
+		return err
+	}
+
+	key := deriveKey(passphrase, salt, argonTime, argonMemory, argonThreads)
+	ks := keystore{
+		Version:    keystoreVersion,
+		PublicKey:  id.PublicKey,
+		Salt:       salt,
+		Time:       argonTime,
+		Memory:     argonMemory,
+		Threads:    argonThreads,
+		Nonce:      nonce[:],
+		Ciphertext: secretbox.Seal(nil, id.PrivateKey.Seed(), &nonce, &key),
+	}
+
+	data, err := json.MarshalIndent(ks, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func deriveKey(passphrase string, salt []byte, time uint32, memory uint32, threads uint8) [32]byte {
+	var key [32]byte
+	copy(key[:], argon2.IDKey([]byte(passphrase), salt, time, memory, threads, 32))
+	return key
+}
+
+type KeystoreError string
+
+func (e KeystoreError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrInvalidKeystore = KeystoreError("invalid keystore file")
+	ErrWrongPassphrase = KeystoreError("wrong passphrase")
+	ErrEmptyPassphrase = KeystoreError("passphrase must not be empty")
+)
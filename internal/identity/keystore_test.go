@@ -0,0 +1,93 @@
+package identity
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadOrCreate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "void", keystoreFile)
+
+	created, err := LoadOrCreate(path, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadOrCreate(path, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(created.PublicKey, loaded.PublicKey) {
+		t.Fatal("reloaded a different identity")
+	}
+
+	if _, err := LoadOrCreate(path, "battery staple"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Fatalf("wrong passphrase: err = %v, want %v", err, ErrWrongPassphrase)
+	}
+}
+
+func TestLoadOrCreateEmptyPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), keystoreFile)
+
+	if _, err := LoadOrCreate(path, ""); !errors.Is(err, ErrEmptyPassphrase) {
+		t.Fatalf("err = %v, want %v", err, ErrEmptyPassphrase)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("keystore written with an empty passphrase: %v", err)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, keystoreFile)
+	if _, err := LoadOrCreate(path, "passphrase"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var valid keystore
+	if err := json.Unmarshal(data, &valid); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		change func(*keystore)
+	}{
+		{"version", func(ks *keystore) { ks.Version = 2 }},
+		{"nonce", func(ks *keystore) { ks.Nonce = ks.Nonce[:12] }},
+		{"salt", func(ks *keystore) { ks.Salt = nil }},
+		{"zero time", func(ks *keystore) { ks.Time = 0 }},
+		{"huge time", func(ks *keystore) { ks.Time = 1 << 31 }},
+		{"zero threads", func(ks *keystore) { ks.Threads = 0 }},
+		{"huge memory", func(ks *keystore) { ks.Memory = 1 << 31 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks := valid
+			tt.change(&ks)
+			data, _ := json.Marshal(ks)
+			path := filepath.Join(dir, tt.name+".json")
+			if err := os.WriteFile(path, data, 0600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadOrCreate(path, "passphrase"); !errors.Is(err, ErrInvalidKeystore) {
+				t.Fatalf("err = %v, want %v", err, ErrInvalidKeystore)
+			}
+		})
+	}
+
+	corrupt := filepath.Join(dir, "corrupt.json")
+	if err := os.WriteFile(corrupt, []byte("not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadOrCreate(corrupt, "passphrase"); !errors.Is(err, ErrInvalidKeystore) {
+		t.Fatalf("corrupt file: err = %v, want %v", err, ErrInvalidKeystore)
+	}
+}
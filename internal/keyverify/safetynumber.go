@@ -0,0 +1,114 @@
+package keyverify
+
+import (
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	safetyNumberVersion    = 0
+	safetyNumberIterations = 5200
+	safetyNumberChunks     = 6
+	safetyNumberChunkSize  = 5
+
+	verificationScheme  = "void"
+	verificationOpaque  = "verify"
+	verificationVersion = "1"
+)
+
+func ComputeSafetyNumber(localIdentity *[32]byte, remoteIdentity *[32]byte) string {
+	local := displayableFingerprint(localIdentity)
+	remote := displayableFingerprint(remoteIdentity)
+
+	if strings.Compare(local, remote) <= 0 {
+		return local + remote
+	}
+	return remote + local
+}
+
+func FormatSafetyNumber(number string) string {
+	blocks := make([]string, 0, len(number)/safetyNumberChunkSize)
+	for i := 0; i+safetyNumberChunkSize <= len(number); i += safetyNumberChunkSize {
+		blocks = append(blocks, number[i:i+safetyNumberChunkSize])
+	}
+	return strings.Join(blocks, " ")
+}
+
+func displayableFingerprint(identityKey *[32]byte) string {
+	var version [2]byte
+	binary.BigEndian.PutUint16(version[:], safetyNumberVersion)
+
+	hash := append(version[:], identityKey[:]...)
+	for i := 0; i < safetyNumberIterations; i++ {
+		sum := sha512.Sum512(append(hash, identityKey[:]...))
+		hash = sum[:]
+	}
+
+	var b strings.Builder
+	for i := 0; i < safetyNumberChunks; i++ {
+		chunk := hash[i*5 : i*5+5]
+		value := uint64(chunk[0])<<32 | uint64(chunk[1])<<24 | uint64(chunk[2])<<16 | uint64(chunk[3])<<8 | uint64(chunk[4])
+		fmt.Fprintf(&b, "%05d", value%100000)
+	}
+	return b.String()
+}
+
+func EncodeVerificationURI(localIdentity *[32]byte, remoteIdentity *[32]byte) string {
+	query := url.Values{}
+	query.Set("v", verificationVersion)
+	query.Set("id", base64.RawURLEncoding.EncodeToString(localIdentity[:]))
+	query.Set("peer", base64.RawURLEncoding.EncodeToString(remoteIdentity[:]))
+
+	u := url.URL{
+		Scheme:   verificationScheme,
+		Opaque:   verificationOpaque,
+		RawQuery: query.Encode(),
+	}
+	return u.String()
+}
+
+func DecodeVerificationURI(uri string) (senderIdentity [32]byte, scannerIdentity [32]byte, err error) {
+	u, err := url.Parse(strings.TrimSpace(uri))
+	if err != nil || u.Scheme != verificationScheme || u.Opaque != verificationOpaque {
+		return senderIdentity, scannerIdentity, ErrInvalidVerificationURI
+	}
+
+	query := u.Query()
+	if query.Get("v") != verificationVersion {
+		return senderIdentity, scannerIdentity, ErrInvalidVerificationURI
+	}
+
+	sender, err := base64.RawURLEncoding.DecodeString(query.Get("id"))
+	if err != nil || len(sender) != 32 {
+		return senderIdentity, scannerIdentity, ErrInvalidVerificationURI
+	}
+	scanner, err := base64.RawURLEncoding.DecodeString(query.Get("peer"))
+	if err != nil || len(scanner) != 32 {
+		return senderIdentity, scannerIdentity, ErrInvalidVerificationURI
+	}
+
+	copy(senderIdentity[:], sender)
+	copy(scannerIdentity[:], scanner)
+	return senderIdentity, scannerIdentity, nil
+}
+
+func ResolveVerificationURI(uri string, localIdentity *[32]byte) ([32]byte, error) {
+	sender, scanner, err := DecodeVerificationURI(uri)
+	if err != nil {
+		return sender, err
+	}
+	if subtle.ConstantTimeCompare(scanner[:], localIdentity[:]) != 1 {
+		return sender, ErrVerificationMismatch
+	}
+	return sender, nil
+}
+
+const (
+	ErrInvalidVerificationURI = TrustError("invalid verification payload")
+	ErrVerificationMismatch   = TrustError("verification payload does not match this conversation")
+)
@@ -0,0 +1,112 @@
+package keyverify
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testIdentities() (*[32]byte, *[32]byte) {
+	var a, b [32]byte
+	for i := range a {
+		a[i] = byte(i + 1)
+		b[i] = byte(0xff - i)
+	}
+	return &a, &b
+}
+
+func TestSafetyNumberSymmetric(t *testing.T) {
+	a, b := testIdentities()
+
+	number := ComputeSafetyNumber(a, b)
+	if reversed := ComputeSafetyNumber(b, a); number != reversed {
+		t.Fatalf("safety number depends on argument order: %s != %s", number, reversed)
+	}
+	if len(number) != 60 || strings.Trim(number, "0123456789") != "" {
+		t.Fatalf("safety number %q is not 60 digits", number)
+	}
+
+	c := *a
+	c[0] ^= 1
+	if ComputeSafetyNumber(&c, b) == number {
+		t.Fatal("different identity produced the same safety number")
+	}
+}
+
+func TestFormatSafetyNumber(t *testing.T) {
+	a, b := testIdentities()
+
+	want := "45701 71925 20877 56121 37718 23929 61681 70083 75951 75363 56551 16450"
+	if got := FormatSafetyNumber(ComputeSafetyNumber(a, b)); got != want {
+		t.Fatalf("FormatSafetyNumber = %q, want %q", got, want)
+	}
+	if got := FormatSafetyNumber("0123456789"); got != "01234 56789" {
+		t.Fatalf("FormatSafetyNumber = %q", got)
+	}
+}
+
+func TestVerificationURIRoundTrip(t *testing.T) {
+	a, b := testIdentities()
+
+	uri := EncodeVerificationURI(a, b)
+	want := "void:verify?id=AQIDBAUGBwgJCgsMDQ4PEBESExQVFhcYGRobHB0eHyA&peer=__79_Pv6-fj39vX08_Lx8O_u7ezr6uno5-bl5OPi4eA&v=1"
+	if uri != want {
+		t.Fatalf("EncodeVerificationURI = %q, want %q", uri, want)
+	}
+
+	sender, scanner, err := DecodeVerificationURI(" " + uri + "\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sender != *a || scanner != *b {
+		t.Fatal("decoded identities do not match the encoded ones")
+	}
+
+	resolved, err := ResolveVerificationURI(uri, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved != *a {
+		t.Fatal("resolved identity is not the sender")
+	}
+
+	if _, err := ResolveVerificationURI(uri, a); !errors.Is(err, ErrVerificationMismatch) {
+		t.Fatalf("scanned by the wrong identity: err = %v", err)
+	}
+}
+
+func TestDecodeVerificationURIRejectsTampering(t *testing.T) {
+	a, b := testIdentities()
+	uri := EncodeVerificationURI(a, b)
+	id := "AQIDBAUGBwgJCgsMDQ4PEBESExQVFhcYGRobHB0eHyA"
+	peer := "__79_Pv6-fj39vX08_Lx8O_u7ezr6uno5-bl5OPi4eA"
+
+	cases := map[string]string{
+		"empty":        "",
+		"scheme":       strings.Replace(uri, "void:", "http:", 1),
+		"opaque":       strings.Replace(uri, "verify", "confirm", 1),
+		"hierarchical": strings.Replace(uri, "void:verify", "void://verify", 1),
+		"version":      strings.Replace(uri, "v=1", "v=2", 1),
+		"no version":   "void:verify?id=" + id + "&peer=" + peer,
+		"no peer":      "void:verify?id=" + id + "&v=1",
+		"bad base64":   strings.Replace(uri, id, "*"+id[1:], 1),
+		"padded":       strings.Replace(uri, id, id+"=", 1),
+		"short id":     strings.Replace(uri, id, id[:40], 1),
+		"long peer":    strings.Replace(uri, peer, peer+"AAAA", 1),
+	}
+	for name, tampered := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := DecodeVerificationURI(tampered); !errors.Is(err, ErrInvalidVerificationURI) {
+				t.Fatalf("DecodeVerificationURI(%q) err = %v", tampered, err)
+			}
+			if _, err := ResolveVerificationURI(tampered, b); !errors.Is(err, ErrInvalidVerificationURI) {
+				t.Fatalf("ResolveVerificationURI(%q) err = %v", tampered, err)
+			}
+		})
+	}
+
+	swapped := "void:verify?id=" + peer + "&peer=" + id + "&v=1"
+	if _, err := ResolveVerificationURI(swapped, b); !errors.Is(err, ErrVerificationMismatch) {
+		t.Fatalf("swapped identities: err = %v", err)
+	}
+}
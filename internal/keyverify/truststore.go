@@ -0,0 +1,217 @@
+package keyverify
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const trustStoreFile = "trust.json"
+
+type TrustLevel string
+
+const (
+	TrustUnverified TrustLevel = "unverified"
+	TrustVerified   TrustLevel = "verified"
+	TrustChanged    TrustLevel = "changed"
+)
+
+type TrustRecord struct {
+	Fingerprint          string     `json:"fingerprint"`
+	Username             string     `json:"username"`
+	Level                TrustLevel `json:"level"`
+	PreviousFingerprints []string   `json:"previousFingerprints"`
+	PreviousUsernames    []string   `json:"previousUsernames"`
+	FirstSeen            time.Time  `json:"firstSeen"`
+	LastSeen             time.Time  `json:"lastSeen"`
+	VerifiedAt           *time.Time `json:"verifiedAt,omitempty"`
+}
+
+type TrustStore struct {
+	path    string
+	records map[string]*TrustRecord
+	mu      sync.RWMutex
+}
+
+func DefaultTrustStorePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "Void", trustStoreFile), nil
+}
+
+func NewTrustStore() *TrustStore {
+	return &TrustStore{
+		records: make(map[string]*TrustRecord),
+	}
+}
+
+func OpenTrustStore(path string) (*TrustStore, error) {
+	ts := NewTrustStore()
+	ts.path = path
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ts, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var records []*TrustRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, ErrInvalidTrustStore
+	}
+	for _, record := range records {
+		ts.records[record.Fingerprint] = record
+	}
+	return ts, nil
+}
+
+func (ts *TrustStore) Observe(fingerprint string, username string) (TrustRecord, string, error) {
+	now := time.Now().UTC()
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	record, exists := ts.records[fingerprint]
+	if !exists {
+		record = &TrustRecord{
+			Fingerprint: fingerprint,
+			Username:    username,
+			Level:       TrustUnverified,
+			FirstSeen:   now,
+		}
+		if previous := ts.latestForUsername(username); previous != nil {
+			record.Level = TrustChanged
+			record.PreviousFingerprints = append([]string{previous.Fingerprint}, previous.PreviousFingerprints...)
+		}
+		ts.records[fingerprint] = record
+	}
+
+	var previousUsername string
+	if record.Username != username {
+		previousUsername = record.Username
+		record.PreviousUsernames = append([]string{previousUsername}, record.PreviousUsernames...)
+		record.Username = username
+	}
+	record.LastSeen = now
+	return *record, previousUsername, ts.save()
+}
+
+func (ts *TrustStore) Get(fingerprint string) (TrustRecord, bool) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	record, exists := ts.records[fingerprint]
+	if !exists {
+		return TrustRecord{}, false
+	}
+	return *record, true
+}
+
+func (ts *TrustStore) Level(fingerprint string) TrustLevel {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	if record, exists := ts.records[fingerprint]; exists {
+		return record.Level
+	}
+	return TrustUnverified
+}
+
+func (ts *TrustStore) Verify(fingerprint string) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	record, exists := ts.records[fingerprint]
+	if !exists {
+		return ErrUnknownFingerprint
+	}
+	now := time.Now().UTC()
+	record.Level = TrustVerified
+	record.VerifiedAt = &now
+	return ts.save()
+}
+
+func (ts *TrustStore) Revoke(fingerprint string) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	record, exists := ts.records[fingerprint]
+	if !exists {
+		return ErrUnknownFingerprint
+	}
+	record.Level = TrustUnverified
+	record.VerifiedAt = nil
+	return ts.save()
+}
+
+func (ts *TrustStore) List() []TrustRecord {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	return ts.sortedRecords()
+}
+
+func (ts *TrustStore) Export() ([]byte, error) {
+	return json.MarshalIndent(ts.List(), "", "  ")
+}
+
+func (ts *TrustStore) latestForUsername(username string) *TrustRecord {
+	var latest *TrustRecord
+	for _, record := range ts.records {
+		if record.Username != username {
+			continue
+		}
+		if latest == nil || record.LastSeen.After(latest.LastSeen) {
+			latest = record
+		}
+	}
+	return latest
+}
+
+func (ts *TrustStore) sortedRecords() []TrustRecord {
+	records := make([]TrustRecord, 0, len(ts.records))
+	for _, record := range ts.records {
+		records = append(records, *record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].FirstSeen.Before(records[j].FirstSeen)
+	})
+	return records
+}
+
+func (ts *TrustStore) save() error {
+	if ts.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(ts.sortedRecords(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(ts.path), 0700); err != nil {
+		return err
+	}
+
+	tmp := ts.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, ts.path)
+}
+
+type TrustError string
+
+func (e TrustError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrInvalidTrustStore  = TrustError("invalid trust store file")
+	ErrUnknownFingerprint = TrustError("unknown fingerprint")
+)
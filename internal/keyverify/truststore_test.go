@@ -0,0 +1,131 @@
+package keyverify
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func observe(t *testing.T, ts *TrustStore, fingerprint string, username string) (TrustRecord, string) {
+	t.Helper()
+	record, previous, err := ts.Observe(fingerprint, username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return record, previous
+}
+
+func TestObserveKeyChange(t *testing.T) {
+	ts := NewTrustStore()
+
+	observe(t, ts, "aaaa", "alex")
+	if err := ts.Verify("aaaa"); err != nil {
+		t.Fatal(err)
+	}
+	record, previous := observe(t, ts, "bbbb", "alex")
+	if record.Level != TrustChanged || previous != "" {
+		t.Fatalf("new key: level %s, previous %q", record.Level, previous)
+	}
+	if want := []string{"aaaa"}; !reflect.DeepEqual(record.PreviousFingerprints, want) {
+		t.Fatalf("PreviousFingerprints = %v, want %v", record.PreviousFingerprints, want)
+	}
+	if ts.Level("aaaa") != TrustVerified {
+		t.Fatal("old key lost its verification")
+	}
+
+	if record, _ := observe(t, ts, "bbbb", "alex"); record.Level != TrustChanged {
+		t.Fatal("changed key unblocked without verification")
+	}
+	if err := ts.Verify("bbbb"); err != nil {
+		t.Fatal(err)
+	}
+	record, _ = observe(t, ts, "cccc", "alex")
+	if want := []string{"bbbb", "aaaa"}; record.Level != TrustChanged || !reflect.DeepEqual(record.PreviousFingerprints, want) {
+		t.Fatalf("second change: level %s, PreviousFingerprints %v, want %v", record.Level, record.PreviousFingerprints, want)
+	}
+
+	if record, _ := observe(t, ts, "dddd", "sam"); record.Level != TrustUnverified || len(record.PreviousFingerprints) != 0 {
+		t.Fatalf("new name: %+v", record)
+	}
+}
+
+func TestObserveRename(t *testing.T) {
+	ts := NewTrustStore()
+
+	observe(t, ts, "aaaa", "alex")
+	record, previous := observe(t, ts, "aaaa", "sam")
+	if previous != "alex" || record.Username != "sam" || record.Level != TrustUnverified {
+		t.Fatalf("unverified rename: %+v, previous %q", record, previous)
+	}
+
+	if err := ts.Verify("aaaa"); err != nil {
+		t.Fatal(err)
+	}
+	if _, previous := observe(t, ts, "aaaa", "sam"); previous != "" {
+		t.Fatalf("same name reported a rename from %q", previous)
+	}
+
+	record, previous = observe(t, ts, "aaaa", "alex")
+	if previous != "sam" || record.Level != TrustVerified || record.VerifiedAt == nil {
+		t.Fatalf("verified rename: %+v, previous %q", record, previous)
+	}
+	if want := []string{"sam", "alex"}; !reflect.DeepEqual(record.PreviousUsernames, want) {
+		t.Fatalf("PreviousUsernames = %v, want %v", record.PreviousUsernames, want)
+	}
+}
+
+func TestTrustStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "void", trustStoreFile)
+	ts, err := OpenTrustStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	observe(t, ts, "aaaa", "alex")
+	observe(t, ts, "aaaa", "sam")
+	if err := ts.Verify("aaaa"); err != nil {
+		t.Fatal(err)
+	}
+	observe(t, ts, "bbbb", "sam")
+
+	reopened, err := OpenTrustStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, ok := reopened.Get("aaaa")
+	if !ok || record.Username != "sam" || record.Level != TrustVerified || len(record.PreviousUsernames) != 1 {
+		t.Fatalf("reopened record: %+v", record)
+	}
+	if record, _ := reopened.Get("bbbb"); record.Level != TrustChanged || len(record.PreviousFingerprints) != 1 {
+		t.Fatalf("reopened changed record: %+v", record)
+	}
+}
+
+func TestObserveReportsSaveError(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "void")
+	ts, err := OpenTrustStore(filepath.Join(dir, trustStoreFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dir, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	record, _, err := ts.Observe("aaaa", "alex")
+	if err == nil {
+		t.Fatal("Observe succeeded without a writable store")
+	}
+	if record.Fingerprint != "aaaa" || ts.Level("aaaa") != TrustUnverified {
+		t.Fatal("record not kept in memory")
+	}
+}
+
+func TestOpenTrustStoreInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), trustStoreFile)
+	if err := os.WriteFile(path, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenTrustStore(path); err != ErrInvalidTrustStore {
+		t.Fatalf("err = %v, want %v", err, ErrInvalidTrustStore)
+	}
+}
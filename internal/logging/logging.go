@@ -0,0 +1,23 @@
+package logging
+
+import (
+	"io"
+	"log/slog"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+func ValidFormat(format string) bool {
+	return format == FormatText || format == FormatJSON
+}
+
+func New(w io.Writer, format string, level slog.Leveler) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if format == FormatJSON {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
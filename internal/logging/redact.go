@@ -0,0 +1,122 @@
+package logging
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+)
+
+type IPMode string
+
+const (
+	IPTruncate IPMode = "truncate"
+	IPFull     IPMode = "full"
+	IPOmit     IPMode = "omit"
+)
+
+type RoomMode string
+
+const (
+	RoomHash RoomMode = "hash"
+	RoomFull RoomMode = "full"
+	RoomOmit RoomMode = "omit"
+)
+
+const roomHashLength = 12
+
+type Redaction struct {
+	IP   IPMode
+	Room RoomMode
+}
+
+func DefaultRedaction() Redaction {
+	return Redaction{
+		IP:   IPTruncate,
+		Room: RoomHash,
+	}
+}
+
+func (m IPMode) Valid() bool {
+	switch m {
+	case IPTruncate, IPFull, IPOmit:
+		return true
+	}
+	return false
+}
+
+func (m RoomMode) Valid() bool {
+	switch m {
+	case RoomHash, RoomFull, RoomOmit:
+		return true
+	}
+	return false
+}
+
+type Redactor struct {
+	mu        sync.Mutex
+	redaction Redaction
+	day       string
+	key       []byte
+}
+
+func NewRedactor(redaction Redaction) *Redactor {
+	return &Redactor{redaction: redaction}
+}
+
+func (r *Redactor) SetRedaction(redaction Redaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.redaction = redaction
+}
+
+func (r *Redactor) IP(ip string) slog.Attr {
+	r.mu.Lock()
+	mode := r.redaction.IP
+	r.mu.Unlock()
+
+	switch mode {
+	case IPFull:
+		return slog.String("ip", ip)
+	case IPOmit:
+		return slog.Attr{}
+	}
+	return slog.String("ip", TruncateIP(ip))
+}
+
+func (r *Redactor) Room(roomID string) slog.Attr {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch r.redaction.Room {
+	case RoomFull:
+		return slog.String("room", roomID)
+	case RoomOmit:
+		return slog.Attr{}
+	}
+
+	day := time.Now().UTC().Format(time.DateOnly)
+	if day != r.day {
+		r.key = make([]byte, 32)
+		rand.Read(r.key)
+		r.day = day
+	}
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(roomID))
+	return slog.String("room", hex.EncodeToString(mac.Sum(nil))[:roomHashLength])
+}
+
+func TruncateIP(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return "invalid"
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String() + "/24"
+	}
+	return parsed.Mask(net.CIDRMask(48, 128)).String() + "/48"
+}
@@ -0,0 +1,185 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	backupTimeFormat    = "20060102-150405"
+	rotateRetryInterval = time.Minute
+)
+
+type RotatingFile struct {
+	mu       sync.Mutex
+	path     string
+	maxSize  int64
+	maxFiles int
+	maxAge   time.Duration
+	file     *os.File
+	size     int64
+	day      string
+
+	stderr    io.Writer
+	rotateErr error
+	retryAt   time.Time
+	retrySize int64
+}
+
+func OpenRotating(path string, maxSize int64, maxFiles int, maxAge time.Duration) (*RotatingFile, error) {
+	f := &RotatingFile{
+		path:     path,
+		maxSize:  maxSize,
+		maxFiles: maxFiles,
+		maxAge:   maxAge,
+		stderr:   os.Stderr,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	f.prune()
+	return f, nil
+}
+
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	full := f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize
+	if (full || day(time.Now()) != f.day) && f.retryDue(len(p)) {
+		f.tryRotate()
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *RotatingFile) retryDue(n int) bool {
+	if f.rotateErr == nil || !time.Now().Before(f.retryAt) {
+		return true
+	}
+	return f.maxSize > 0 && f.size+int64(n) > f.retrySize
+}
+
+func (f *RotatingFile) tryRotate() {
+	err := f.rotate()
+	if err == nil {
+		f.rotateErr = nil
+		return
+	}
+	if f.rotateErr == nil {
+		fmt.Fprintf(f.stderr, "Cannot rotate log file %s, still writing to the current file: %v\n", f.path, err)
+	}
+	f.rotateErr = err
+	f.retryAt = time.Now().Add(rotateRetryInterval)
+	f.retrySize = f.size + f.maxSize
+}
+
+func (f *RotatingFile) Path() string {
+	return f.path
+}
+
+func (f *RotatingFile) SetLimits(maxSize int64, maxFiles int, maxAge time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.maxSize = maxSize
+	f.maxFiles = maxFiles
+	f.maxAge = maxAge
+}
+
+func (f *RotatingFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	previous := f.file
+	if err := f.open(); err != nil {
+		return err
+	}
+	if previous != nil {
+		previous.Close()
+	}
+	return nil
+}
+
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.day = day(info.ModTime())
+	if f.size == 0 {
+		f.day = day(time.Now())
+	}
+	return nil
+}
+
+func (f *RotatingFile) rotate() error {
+	backup := f.path + "." + time.Now().Format(backupTimeFormat)
+	for i := 1; fileExists(backup); i++ {
+		backup = fmt.Sprintf("%s.%s.%d", f.path, time.Now().Format(backupTimeFormat), i)
+	}
+	if err := os.Rename(f.path, backup); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	previous := f.file
+	if err := f.open(); err != nil {
+		return err
+	}
+	previous.Close()
+	f.prune()
+	return nil
+}
+
+func (f *RotatingFile) prune() {
+	backups, _ := filepath.Glob(f.path + ".[0-9]*")
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+
+	for i, backup := range backups {
+		info, err := os.Stat(backup)
+		if err != nil {
+			continue
+		}
+		tooMany := f.maxFiles > 0 && i >= f.maxFiles
+		tooOld := f.maxAge > 0 && time.Since(info.ModTime()) > f.maxAge
+		if tooMany || tooOld {
+			os.Remove(backup)
+		}
+	}
+}
+
+func day(t time.Time) string {
+	return t.Format(time.DateOnly)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,109 @@
+package logging
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func write(t *testing.T, f *RotatingFile, data string) {
+	t.Helper()
+	if n, err := f.Write([]byte(data)); err != nil || n != len(data) {
+		t.Fatalf("Write(%q) = %d, %v", data, n, err)
+	}
+}
+
+func expectContent(t *testing.T, path, want string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != want {
+		t.Fatalf("%s contains %q, want %q", path, data, want)
+	}
+}
+
+func TestRotateBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "void.log")
+	f, err := OpenRotating(path, 10, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	write(t, f, "0123456789")
+	write(t, f, "next")
+	expectContent(t, path, "next")
+
+	backups, _ := filepath.Glob(path + ".[0-9]*")
+	if len(backups) != 1 {
+		t.Fatalf("%d backups, want 1", len(backups))
+	}
+	expectContent(t, backups[0], "0123456789")
+}
+
+func TestRotateRetriesFailedReopen(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "void.log")
+	f, err := OpenRotating(path, 10, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var stderr bytes.Buffer
+	f.stderr = &stderr
+
+	write(t, f, "0123456789")
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	write(t, f, "kept")
+
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	write(t, f, "x")
+	if fileExists(path) {
+		t.Fatal("rotation retried before the backoff passed")
+	}
+	write(t, f, "reopened")
+	expectContent(t, path, "reopened")
+
+	if reports := strings.Count(stderr.String(), "\n"); reports != 1 {
+		t.Fatalf("failure reported %d times, want once: %q", reports, stderr.String())
+	}
+}
+
+func TestRotateFailedRenameReportedOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), strings.Repeat("v", 250)+".log")
+	f, err := OpenRotating(path, 10, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var stderr bytes.Buffer
+	f.stderr = &stderr
+
+	var want strings.Builder
+	for _, data := range []string{"0123456789", "abc", "def", "0123456789", "0123456789", "0123456789"} {
+		write(t, f, data)
+		want.WriteString(data)
+	}
+	expectContent(t, path, want.String())
+
+	if f.rotateErr == nil {
+		t.Fatal("rename failure not tracked")
+	}
+	if reports := strings.Count(stderr.String(), "\n"); reports != 1 || !strings.Contains(stderr.String(), f.rotateErr.Error()) {
+		t.Fatalf("failure reported as %q, want a single report of %v", stderr.String(), f.rotateErr)
+	}
+	if backups, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "*.[0-9]*")); len(backups) != 0 {
+		t.Fatalf("unexpected backups %v", backups)
+	}
+}
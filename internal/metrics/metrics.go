@@ -0,0 +1,192 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+type metric interface {
+	write(w io.Writer)
+}
+
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = append(r.metrics, m)
+}
+
+func (r *Registry) Write(w io.Writer) {
+	r.mu.Lock()
+	metrics := append([]metric(nil), r.metrics...)
+	r.mu.Unlock()
+
+	for _, m := range metrics {
+		m.write(w)
+	}
+}
+
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		r.Write(w)
+	})
+}
+
+type Counter struct {
+	value atomic.Uint64
+}
+
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+func (c *Counter) Add(n uint64) {
+	c.value.Add(n)
+}
+
+func (c *Counter) Value() uint64 {
+	return c.value.Load()
+}
+
+type CounterVec struct {
+	name   string
+	help   string
+	label  string
+	mu     sync.RWMutex
+	values map[string]*Counter
+}
+
+func (r *Registry) CounterVec(name string, help string, label string, values ...string) *CounterVec {
+	v := &CounterVec{
+		name:   name,
+		help:   help,
+		label:  label,
+		values: make(map[string]*Counter),
+	}
+	for _, value := range values {
+		v.values[value] = &Counter{}
+	}
+	r.register(v)
+	return v
+}
+
+func (v *CounterVec) With(value string) *Counter {
+	v.mu.RLock()
+	c, exists := v.values[value]
+	v.mu.RUnlock()
+	if exists {
+		return c
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if c, exists = v.values[value]; !exists {
+		c = &Counter{}
+		v.values[value] = c
+	}
+	return c
+}
+
+func (v *CounterVec) write(w io.Writer) {
+	header(w, v.name, v.help, "counter")
+
+	v.mu.RLock()
+	values := make([]string, 0, len(v.values))
+	for value := range v.values {
+		values = append(values, value)
+	}
+	v.mu.RUnlock()
+	sort.Strings(values)
+
+	for _, value := range values {
+		fmt.Fprintf(w, "%s{%s=%s} %d\n", v.name, v.label, quote(value), v.With(value).Value())
+	}
+}
+
+type valueFunc struct {
+	name  string
+	help  string
+	kind  string
+	value func() float64
+}
+
+func (r *Registry) CounterFunc(name string, help string, value func() float64) {
+	r.register(&valueFunc{name: name, help: help, kind: "counter", value: value})
+}
+
+func (r *Registry) GaugeFunc(name string, help string, value func() float64) {
+	r.register(&valueFunc{name: name, help: help, kind: "gauge", value: value})
+}
+
+func (f *valueFunc) write(w io.Writer) {
+	header(w, f.name, f.help, f.kind)
+	fmt.Fprintf(w, "%s %s\n", f.name, formatFloat(f.value()))
+}
+
+type histogramFunc struct {
+	name    string
+	help    string
+	buckets []float64
+	observe func(observe func(float64))
+}
+
+func (r *Registry) HistogramFunc(name string, help string, buckets []float64, observe func(observe func(float64))) {
+	r.register(&histogramFunc{name: name, help: help, buckets: buckets, observe: observe})
+}
+
+func (h *histogramFunc) write(w io.Writer) {
+	counts := make([]uint64, len(h.buckets))
+	var count uint64
+	var sum float64
+	h.observe(func(value float64) {
+		for i, bound := range h.buckets {
+			if value <= bound {
+				counts[i]++
+			}
+		}
+		count++
+		sum += value
+	})
+
+	header(w, h.name, h.help, "histogram")
+	for i, bound := range h.buckets {
+		fmt.Fprintf(w, "%s_bucket{le=%s} %d\n", h.name, quote(formatFloat(bound)), counts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", h.name, count)
+	fmt.Fprintf(w, "%s_sum %s\n", h.name, formatFloat(sum))
+	fmt.Fprintf(w, "%s_count %d\n", h.name, count)
+}
+
+func header(w io.Writer, name string, help string, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+}
+
+func quote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
+
+func formatFloat(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func scrape(r *Registry) string {
+	var out strings.Builder
+	r.Write(&out)
+	return out.String()
+}
+
+func TestCounterVecFormat(t *testing.T) {
+	r := NewRegistry()
+	v := r.CounterVec("void_test_total", "Test counter.\nSecond line.", "reason", "b", "a")
+	v.With("a").Add(3)
+	v.With(`odd"value\`).Inc()
+
+	want := `# HELP void_test_total Test counter.\nSecond line.
+# TYPE void_test_total counter
+void_test_total{reason="a"} 3
+void_test_total{reason="b"} 0
+void_test_total{reason="odd\"value\\"} 1
+`
+	if got := scrape(r); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestValueFuncFormat(t *testing.T) {
+	r := NewRegistry()
+	r.GaugeFunc("void_gauge", "A gauge.", func() float64 { return 1.5 })
+	r.CounterFunc("void_counter_total", "A counter.", func() float64 { return 42 })
+
+	want := `# HELP void_gauge A gauge.
+# TYPE void_gauge gauge
+void_gauge 1.5
+# HELP void_counter_total A counter.
+# TYPE void_counter_total counter
+void_counter_total 42
+`
+	if got := scrape(r); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHistogramFuncFormat(t *testing.T) {
+	r := NewRegistry()
+	r.HistogramFunc("void_size", "Sizes.", []float64{1, 2.5, 10}, func(observe func(float64)) {
+		for _, value := range []float64{1, 2, 3, 50} {
+			observe(value)
+		}
+	})
+
+	want := `# HELP void_size Sizes.
+# TYPE void_size histogram
+void_size_bucket{le="1"} 1
+void_size_bucket{le="2.5"} 2
+void_size_bucket{le="10"} 3
+void_size_bucket{le="+Inf"} 4
+void_size_sum 56
+void_size_count 4
+`
+	if got := scrape(r); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	r.GaugeFunc("void_gauge", "A gauge.", func() float64 { return 0 })
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if got := rec.Header().Get("Content-Type"); got != contentType {
+		t.Fatalf("Content-Type = %q, want %q", got, contentType)
+	}
+	if !strings.Contains(rec.Body.String(), "void_gauge 0\n") {
+		t.Fatalf("body = %q", rec.Body.String())
+	}
+}
@@ -0,0 +1,55 @@
+package protocol
+
+const (
+	Version    = 2
+	MinVersion = 1
+
+	VersionCreateRoom = 2
+)
+
+const (
+	FeatureFraming      = "framing"
+	FeatureRatchet      = "ratchet"
+	FeatureSenderKeys   = "sender-keys"
+	FeatureResume       = "resume"
+	FeatureRateLimit    = "rate-limit"
+	FeatureDropNotice   = "drop-notice"
+	FeatureFileTransfer = "file-transfer"
+	FeatureMembership   = "membership"
+)
+
+func Has(features []string, feature string) bool {
+	for _, f := range features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+func Missing(have []string, want []string) []string {
+	var missing []string
+	for _, feature := range want {
+		if !Has(have, feature) {
+			missing = append(missing, feature)
+		}
+	}
+	return missing
+}
+
+func Common(a []string, b []string) []string {
+	var common []string
+	for _, feature := range a {
+		if Has(b, feature) {
+			common = append(common, feature)
+		}
+	}
+	return common
+}
+
+func Choose(minVersion uint32, maxVersion uint32) (uint32, bool) {
+	if maxVersion < MinVersion || minVersion > Version {
+		return 0, false
+	}
+	return min(maxVersion, Version), true
+}
@@ -0,0 +1,61 @@
+package protocol
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChoose(t *testing.T) {
+	tests := []struct {
+		min, max uint32
+		want     uint32
+		ok       bool
+	}{
+		{MinVersion, Version, Version, true},
+		{MinVersion, MinVersion, MinVersion, true},
+		{Version, Version + 5, Version, true},
+		{0, Version + 5, Version, true},
+		{Version + 1, Version + 5, 0, false},
+		{0, MinVersion - 1, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := Choose(tt.min, tt.max)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Choose(%d, %d) = %d, %v, want %d, %v", tt.min, tt.max, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestMissing(t *testing.T) {
+	tests := []struct {
+		have, want []string
+		missing    []string
+	}{
+		{nil, nil, nil},
+		{[]string{FeatureRatchet}, nil, nil},
+		{nil, []string{FeatureRatchet}, []string{FeatureRatchet}},
+		{[]string{FeatureRatchet, FeatureResume}, []string{FeatureResume}, nil},
+		{[]string{FeatureRatchet}, []string{FeatureResume, FeatureRatchet, "teleport"}, []string{FeatureResume, "teleport"}},
+	}
+	for _, tt := range tests {
+		if got := Missing(tt.have, tt.want); !reflect.DeepEqual(got, tt.missing) {
+			t.Errorf("Missing(%v, %v) = %v, want %v", tt.have, tt.want, got, tt.missing)
+		}
+	}
+}
+
+func TestCommon(t *testing.T) {
+	tests := []struct {
+		a, b   []string
+		common []string
+	}{
+		{nil, []string{FeatureRatchet}, nil},
+		{[]string{FeatureRatchet, "teleport", FeatureResume}, []string{FeatureResume, FeatureRatchet}, []string{FeatureRatchet, FeatureResume}},
+		{[]string{"teleport"}, []string{FeatureRatchet}, nil},
+	}
+	for _, tt := range tests {
+		if got := Common(tt.a, tt.b); !reflect.DeepEqual(got, tt.common) {
+			t.Errorf("Common(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.common)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package ratchet
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"io"
+
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	rootInfo    = "void-ratchet-root"
+	messageInfo = "void-ratchet-message"
+	sharedInfo  = "void-ratchet-x3dh"
+)
+
+var (
+	chainMessageSeed = []byte{0x01}
+	chainNextSeed    = []byte{0x02}
+)
+
+func dh(privateKey *[32]byte, publicKey *[32]byte) ([32]byte, error) {
+	var out [32]byte
+	shared, err := curve25519.X25519(privateKey[:], publicKey[:])
+	if err != nil {
+		return out, err
+	}
+	copy(out[:], shared)
+	return out, nil
+}
+
+func kdfRoot(rootKey *[32]byte, dhOut *[32]byte) (newRootKey [32]byte, chainKey [32]byte) {
+	r := hkdf.New(sha256.New, dhOut[:], rootKey[:], []byte(rootInfo))
+	io.ReadFull(r, newRootKey[:])
+	io.ReadFull(r, chainKey[:])
+	return newRootKey, chainKey
+}
+
+func kdfChain(chainKey *[32]byte) (nextChainKey [32]byte, messageKey [32]byte) {
+	mac := hmac.New(sha256.New, chainKey[:])
+	mac.Write(chainMessageSeed)
+	copy(messageKey[:], mac.Sum(nil))
+
+	mac = hmac.New(sha256.New, chainKey[:])
+	mac.Write(chainNextSeed)
+	copy(nextChainKey[:], mac.Sum(nil))
+	return nextChainKey, messageKey
+}
+
+func messageSecrets(messageKey *[32]byte, header []byte) (key [32]byte, nonce [24]byte) {
+	info := append([]byte(messageInfo), header...)
+	r := hkdf.New(sha256.New, messageKey[:], nil, info)
+	io.ReadFull(r, key[:])
+	io.ReadFull(r, nonce[:])
+	return key, nonce
+}
@@ -0,0 +1,278 @@
+package ratchet
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"sync"
+
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/nacl/box"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+const (
+	HeaderSize = 32 + 4 + 4
+
+	MaxSkip          = 1000
+	MaxSkippedKeys   = 2000
+	maxMessageLength = 65536
+)
+
+type skippedKey struct {
+	ratchetKey [32]byte
+	n          uint32
+}
+
+type state struct {
+	rootKey      [32]byte
+	sendingKey   [32]byte
+	sendingPub   [32]byte
+	remoteKey    [32]byte
+	sendChain    [32]byte
+	recvChain    [32]byte
+	hasRecvChain bool
+	sendN        uint32
+	recvN        uint32
+	prevN        uint32
+}
+
+type Session struct {
+	state
+	skipped      map[skippedKey][32]byte
+	skippedOrder []skippedKey
+	mu           sync.Mutex
+}
+
+func NewSession(localPrivate *[32]byte, localPublic *[32]byte, remotePublic *[32]byte, localIdentity []byte, remoteIdentity []byte) (*Session, error) {
+	shared, err := dh(localPrivate, remotePublic)
+	if err != nil {
+		return nil, err
+	}
+
+	initiator := bytes.Compare(localPublic[:], remotePublic[:]) < 0
+	var rootKey [32]byte
+	if initiator {
+		rootKey = deriveSharedSecret(&shared, localIdentity, remoteIdentity)
+	} else {
+		rootKey = deriveSharedSecret(&shared, remoteIdentity, localIdentity)
+	}
+
+	s := &Session{
+		state: state{
+			sendingKey: *localPrivate,
+			sendingPub: *localPublic,
+			remoteKey:  *remotePublic,
+		},
+		skipped: make(map[skippedKey][32]byte),
+	}
+
+	if initiator {
+		s.rootKey, s.sendChain = kdfRoot(&rootKey, &shared)
+		return s, nil
+	}
+
+	s.rootKey, s.recvChain = kdfRoot(&rootKey, &shared)
+	s.hasRecvChain = true
+	if err := s.rotateSendingKey(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func deriveSharedSecret(shared *[32]byte, initiatorIdentity []byte, responderIdentity []byte) [32]byte {
+	info := make([]byte, 0, len(sharedInfo)+len(initiatorIdentity)+len(responderIdentity))
+	info = append(info, sharedInfo...)
+	info = append(info, initiatorIdentity...)
+	info = append(info, responderIdentity...)
+
+	var out [32]byte
+	io.ReadFull(hkdf.New(sha256.New, shared[:], nil, info), out[:])
+	return out
+}
+
+func (s *Session) Encrypt(plaintext []byte) ([]byte, error) {
+	if len(plaintext) > maxMessageLength {
+		return nil, ErrMessageTooLarge
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var messageKey [32]byte
+	s.sendChain, messageKey = kdfChain(&s.sendChain)
+
+	header := encodeHeader(&s.sendingPub, s.prevN, s.sendN)
+	s.sendN++
+
+	key, nonce := messageSecrets(&messageKey, header)
+	return secretbox.Seal(header, plaintext, &nonce, &key), nil
+}
+
+func (s *Session) Decrypt(message []byte) ([]byte, error) {
+	if len(message) < HeaderSize+secretbox.Overhead {
+		return nil, ErrInvalidMessage
+	}
+	header := message[:HeaderSize]
+	ciphertext := message[HeaderSize:]
+	ratchetKey, pn, n := decodeHeader(header)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := skippedKey{ratchetKey: ratchetKey, n: n}
+	if messageKey, ok := s.skipped[id]; ok {
+		plaintext, err := open(&messageKey, header, ciphertext)
+		if err != nil {
+			return nil, err
+		}
+		s.forgetSkipped(id)
+		return plaintext, nil
+	}
+
+	next := s.state
+	var staged []skippedKey
+	stagedKeys := make(map[skippedKey][32]byte)
+
+	if ratchetKey != next.remoteKey || !next.hasRecvChain {
+		if err := next.skipMessageKeys(pn, stagedKeys, &staged); err != nil {
+			return nil, err
+		}
+		if err := next.dhRatchet(&ratchetKey); err != nil {
+			return nil, err
+		}
+	}
+	if err := next.skipMessageKeys(n, stagedKeys, &staged); err != nil {
+		return nil, err
+	}
+
+	var messageKey [32]byte
+	next.recvChain, messageKey = kdfChain(&next.recvChain)
+	next.recvN++
+
+	plaintext, err := open(&messageKey, header, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	s.state = next
+	for _, id := range staged {
+		s.rememberSkipped(id, stagedKeys[id])
+	}
+	return plaintext, nil
+}
+
+func (s *state) skipMessageKeys(until uint32, stagedKeys map[skippedKey][32]byte, staged *[]skippedKey) error {
+	if !s.hasRecvChain {
+		return nil
+	}
+	if until < s.recvN {
+		return ErrDecryptionFailed
+	}
+	if until-s.recvN > MaxSkip {
+		return ErrTooManySkipped
+	}
+	for s.recvN < until {
+		var messageKey [32]byte
+		s.recvChain, messageKey = kdfChain(&s.recvChain)
+		id := skippedKey{ratchetKey: s.remoteKey, n: s.recvN}
+		stagedKeys[id] = messageKey
+		*staged = append(*staged, id)
+		s.recvN++
+	}
+	return nil
+}
+
+func (s *state) dhRatchet(ratchetKey *[32]byte) error {
+	s.prevN = s.sendN
+	s.sendN = 0
+	s.recvN = 0
+	s.remoteKey = *ratchetKey
+
+	shared, err := dh(&s.sendingKey, &s.remoteKey)
+	if err != nil {
+		return err
+	}
+	s.rootKey, s.recvChain = kdfRoot(&s.rootKey, &shared)
+	s.hasRecvChain = true
+
+	return s.rotateSendingKey()
+}
+
+func (s *state) rotateSendingKey() error {
+	publicKey, privateKey, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	s.sendingKey = *privateKey
+	s.sendingPub = *publicKey
+
+	shared, err := dh(&s.sendingKey, &s.remoteKey)
+	if err != nil {
+		return err
+	}
+	s.rootKey, s.sendChain = kdfRoot(&s.rootKey, &shared)
+	return nil
+}
+
+func (s *Session) rememberSkipped(id skippedKey, messageKey [32]byte) {
+	if _, exists := s.skipped[id]; exists {
+		return
+	}
+	s.skipped[id] = messageKey
+	s.skippedOrder = append(s.skippedOrder, id)
+	for len(s.skippedOrder) > MaxSkippedKeys {
+		delete(s.skipped, s.skippedOrder[0])
+		s.skippedOrder = s.skippedOrder[1:]
+	}
+}
+
+func (s *Session) forgetSkipped(id skippedKey) {
+	delete(s.skipped, id)
+	for i, candidate := range s.skippedOrder {
+		if candidate == id {
+			s.skippedOrder = append(s.skippedOrder[:i], s.skippedOrder[i+1:]...)
+			break
+		}
+	}
+}
+
+func open(messageKey *[32]byte, header []byte, ciphertext []byte) ([]byte, error) {
+	key, nonce := messageSecrets(messageKey, header)
+	plaintext, ok := secretbox.Open(nil, ciphertext, &nonce, &key)
+	if !ok {
+		return nil, ErrDecryptionFailed
+	}
+	return plaintext, nil
+}
+
+func encodeHeader(ratchetKey *[32]byte, pn uint32, n uint32) []byte {
+	header := make([]byte, HeaderSize)
+	copy(header, ratchetKey[:])
+	binary.BigEndian.PutUint32(header[32:], pn)
+	binary.BigEndian.PutUint32(header[36:], n)
+	return header
+}
+
+func decodeHeader(header []byte) (ratchetKey [32]byte, pn uint32, n uint32) {
+	copy(ratchetKey[:], header[:32])
+	pn = binary.BigEndian.Uint32(header[32:])
+	n = binary.BigEndian.Uint32(header[36:])
+	return ratchetKey, pn, n
+}
+
+type RatchetError string
+
+func (e RatchetError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrMessageTooLarge  = RatchetError("message too large")
+	ErrInvalidMessage   = RatchetError("invalid ratchet message")
+	ErrDecryptionFailed = RatchetError("ratchet decryption failed")
+	ErrTooManySkipped   = RatchetError("too many skipped messages")
+	ErrUnknownSenderKey = RatchetError("unknown sender key")
+)
@@ -0,0 +1,156 @@
+package ratchet
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+func newPair(t *testing.T) (*Session, *Session) {
+	t.Helper()
+	alicePub, alicePriv, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bobPub, bobPriv, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice, err := NewSession(alicePriv, alicePub, bobPub, []byte("alice"), []byte("bob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := NewSession(bobPriv, bobPub, alicePub, []byte("bob"), []byte("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return alice, bob
+}
+
+func encrypt(t *testing.T, s *Session, plaintext string) []byte {
+	t.Helper()
+	message, err := s.Encrypt([]byte(plaintext))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return message
+}
+
+func expectPlaintext(t *testing.T, s *Session, message []byte, want string) {
+	t.Helper()
+	got, err := s.Decrypt(message)
+	if err != nil {
+		t.Fatalf("Decrypt(%q): %v", want, err)
+	}
+	if string(got) != want {
+		t.Fatalf("Decrypt = %q, want %q", got, want)
+	}
+}
+
+func TestSessionRoundTrip(t *testing.T) {
+	alice, bob := newPair(t)
+
+	for i := 0; i < 3; i++ {
+		expectPlaintext(t, bob, encrypt(t, alice, "ping"), "ping")
+		expectPlaintext(t, alice, encrypt(t, bob, "pong"), "pong")
+	}
+	expectPlaintext(t, bob, encrypt(t, alice, "one"), "one")
+	expectPlaintext(t, bob, encrypt(t, alice, "two"), "two")
+}
+
+func TestSessionOutOfOrder(t *testing.T) {
+	alice, bob := newPair(t)
+
+	first := encrypt(t, alice, "first")
+	second := encrypt(t, alice, "second")
+	third := encrypt(t, alice, "third")
+
+	expectPlaintext(t, bob, third, "third")
+	expectPlaintext(t, bob, first, "first")
+
+	reply := encrypt(t, bob, "reply")
+	expectPlaintext(t, alice, reply, "reply")
+	fourth := encrypt(t, alice, "fourth")
+
+	expectPlaintext(t, bob, fourth, "fourth")
+	expectPlaintext(t, bob, second, "second")
+}
+
+func TestSessionReplay(t *testing.T) {
+	alice, bob := newPair(t)
+
+	first := encrypt(t, alice, "first")
+	second := encrypt(t, alice, "second")
+	expectPlaintext(t, bob, second, "second")
+	expectPlaintext(t, bob, first, "first")
+
+	for _, message := range [][]byte{first, second} {
+		if _, err := bob.Decrypt(message); !errors.Is(err, ErrDecryptionFailed) {
+			t.Fatalf("replay: err = %v, want %v", err, ErrDecryptionFailed)
+		}
+	}
+}
+
+func TestSessionTampered(t *testing.T) {
+	alice, bob := newPair(t)
+
+	message := encrypt(t, alice, "hello")
+	for _, i := range []int{0, 35, HeaderSize - 1, HeaderSize, len(message) - 1} {
+		tampered := bytes.Clone(message)
+		tampered[i] ^= 1
+		if _, err := bob.Decrypt(tampered); err == nil {
+			t.Fatalf("byte %d flipped: Decrypt succeeded", i)
+		}
+	}
+	if _, err := bob.Decrypt(message[:HeaderSize]); !errors.Is(err, ErrInvalidMessage) {
+		t.Fatalf("short message: err = %v, want %v", err, ErrInvalidMessage)
+	}
+	expectPlaintext(t, bob, message, "hello")
+}
+
+func TestSessionTooManySkipped(t *testing.T) {
+	alice, bob := newPair(t)
+
+	for i := 0; i <= MaxSkip; i++ {
+		encrypt(t, alice, "skipped")
+	}
+	message := encrypt(t, alice, "late")
+	if _, err := bob.Decrypt(message); !errors.Is(err, ErrTooManySkipped) {
+		t.Fatalf("err = %v, want %v", err, ErrTooManySkipped)
+	}
+
+	alice, bob = newPair(t)
+	for i := 0; i < MaxSkip; i++ {
+		encrypt(t, alice, "skipped")
+	}
+	expectPlaintext(t, bob, encrypt(t, alice, "edge"), "edge")
+}
+
+func TestSessionIdentityMismatch(t *testing.T) {
+	alicePub, alicePriv, _ := box.GenerateKey(rand.Reader)
+	bobPub, bobPriv, _ := box.GenerateKey(rand.Reader)
+
+	alice, err := NewSession(alicePriv, alicePub, bobPub, []byte("alice"), []byte("bob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := NewSession(bobPriv, bobPub, alicePub, []byte("bob"), []byte("mallory"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := bob.Decrypt(encrypt(t, alice, "hello")); !errors.Is(err, ErrDecryptionFailed) {
+		t.Fatalf("err = %v, want %v", err, ErrDecryptionFailed)
+	}
+}
+
+func TestSessionMessageTooLarge(t *testing.T) {
+	alice, _ := newPair(t)
+
+	if _, err := alice.Encrypt(make([]byte, maxMessageLength+1)); !errors.Is(err, ErrMessageTooLarge) {
+		t.Fatalf("err = %v, want %v", err, ErrMessageTooLarge)
+	}
+}
@@ -0,0 +1,164 @@
+package ratchet
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"sync"
+
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+const SenderKeyHeaderSize = 4 + 4
+
+type SenderKey struct {
+	keyID     uint32
+	chainKey  [32]byte
+	iteration uint32
+	mu        sync.Mutex
+}
+
+func NewSenderKey() (*SenderKey, error) {
+	var seed [36]byte
+	if _, err := io.ReadFull(rand.Reader, seed[:]); err != nil {
+		return nil, err
+	}
+
+	k := &SenderKey{keyID: binary.BigEndian.Uint32(seed[:4])}
+	copy(k.chainKey[:], seed[4:])
+	return k, nil
+}
+
+func (k *SenderKey) KeyID() uint32 {
+	return k.keyID
+}
+
+func (k *SenderKey) Material() (keyID uint32, chainKey [32]byte, iteration uint32) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.keyID, k.chainKey, k.iteration
+}
+
+func (k *SenderKey) Encrypt(plaintext []byte) ([]byte, error) {
+	if len(plaintext) > maxMessageLength {
+		return nil, ErrMessageTooLarge
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	var messageKey [32]byte
+	k.chainKey, messageKey = kdfChain(&k.chainKey)
+
+	header := encodeSenderKeyHeader(k.keyID, k.iteration)
+	k.iteration++
+
+	key, nonce := messageSecrets(&messageKey, header)
+	return secretbox.Seal(header, plaintext, &nonce, &key), nil
+}
+
+type SenderKeyReceiver struct {
+	keyID        uint32
+	chainKey     [32]byte
+	iteration    uint32
+	skipped      map[uint32][32]byte
+	skippedOrder []uint32
+	mu           sync.Mutex
+}
+
+func NewSenderKeyReceiver(keyID uint32, chainKey [32]byte, iteration uint32) *SenderKeyReceiver {
+	return &SenderKeyReceiver{
+		keyID:     keyID,
+		chainKey:  chainKey,
+		iteration: iteration,
+		skipped:   make(map[uint32][32]byte),
+	}
+}
+
+func (r *SenderKeyReceiver) KeyID() uint32 {
+	return r.keyID
+}
+
+func (r *SenderKeyReceiver) Decrypt(message []byte) ([]byte, error) {
+	if len(message) < SenderKeyHeaderSize+secretbox.Overhead {
+		return nil, ErrInvalidMessage
+	}
+	header := message[:SenderKeyHeaderSize]
+	ciphertext := message[SenderKeyHeaderSize:]
+	keyID, iteration := decodeSenderKeyHeader(header)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if keyID != r.keyID {
+		return nil, ErrUnknownSenderKey
+	}
+
+	if messageKey, ok := r.skipped[iteration]; ok {
+		plaintext, err := open(&messageKey, header, ciphertext)
+		if err != nil {
+			return nil, err
+		}
+		r.forgetSkipped(iteration)
+		return plaintext, nil
+	}
+
+	if iteration < r.iteration {
+		return nil, ErrDecryptionFailed
+	}
+	if iteration-r.iteration > MaxSkip {
+		return nil, ErrTooManySkipped
+	}
+
+	chainKey := r.chainKey
+	skipped := make([][32]byte, 0, iteration-r.iteration)
+	for i := r.iteration; i < iteration; i++ {
+		var messageKey [32]byte
+		chainKey, messageKey = kdfChain(&chainKey)
+		skipped = append(skipped, messageKey)
+	}
+
+	var messageKey [32]byte
+	chainKey, messageKey = kdfChain(&chainKey)
+	plaintext, err := open(&messageKey, header, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, key := range skipped {
+		r.rememberSkipped(r.iteration+uint32(i), key)
+	}
+	r.chainKey = chainKey
+	r.iteration = iteration + 1
+	return plaintext, nil
+}
+
+func (r *SenderKeyReceiver) rememberSkipped(iteration uint32, messageKey [32]byte) {
+	r.skipped[iteration] = messageKey
+	r.skippedOrder = append(r.skippedOrder, iteration)
+	for len(r.skippedOrder) > MaxSkippedKeys {
+		delete(r.skipped, r.skippedOrder[0])
+		r.skippedOrder = r.skippedOrder[1:]
+	}
+}
+
+func (r *SenderKeyReceiver) forgetSkipped(iteration uint32) {
+	delete(r.skipped, iteration)
+	for i, candidate := range r.skippedOrder {
+		if candidate == iteration {
+			r.skippedOrder = append(r.skippedOrder[:i], r.skippedOrder[i+1:]...)
+			break
+		}
+	}
+}
+
+func encodeSenderKeyHeader(keyID uint32, iteration uint32) []byte {
+	header := make([]byte, SenderKeyHeaderSize)
+	binary.BigEndian.PutUint32(header, keyID)
+	binary.BigEndian.PutUint32(header[4:], iteration)
+	return header
+}
+
+func decodeSenderKeyHeader(header []byte) (keyID uint32, iteration uint32) {
+	return binary.BigEndian.Uint32(header), binary.BigEndian.Uint32(header[4:])
+}
@@ -0,0 +1,117 @@
+package ratchet
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func newSenderPair(t *testing.T) (*SenderKey, *SenderKeyReceiver) {
+	t.Helper()
+	key, err := NewSenderKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, NewSenderKeyReceiver(key.Material())
+}
+
+func seal(t *testing.T, k *SenderKey, plaintext string) []byte {
+	t.Helper()
+	message, err := k.Encrypt([]byte(plaintext))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return message
+}
+
+func expectOpen(t *testing.T, r *SenderKeyReceiver, message []byte, want string) {
+	t.Helper()
+	got, err := r.Decrypt(message)
+	if err != nil {
+		t.Fatalf("Decrypt(%q): %v", want, err)
+	}
+	if string(got) != want {
+		t.Fatalf("Decrypt = %q, want %q", got, want)
+	}
+}
+
+func TestSenderKeyRoundTrip(t *testing.T) {
+	key, receiver := newSenderPair(t)
+
+	for _, text := range []string{"one", "two", "three"} {
+		expectOpen(t, receiver, seal(t, key, text), text)
+	}
+
+	late := NewSenderKeyReceiver(key.Material())
+	expectOpen(t, late, seal(t, key, "four"), "four")
+}
+
+func TestSenderKeyOutOfOrder(t *testing.T) {
+	key, receiver := newSenderPair(t)
+
+	first := seal(t, key, "first")
+	second := seal(t, key, "second")
+	third := seal(t, key, "third")
+
+	expectOpen(t, receiver, third, "third")
+	expectOpen(t, receiver, first, "first")
+	expectOpen(t, receiver, second, "second")
+}
+
+func TestSenderKeyReplay(t *testing.T) {
+	key, receiver := newSenderPair(t)
+
+	first := seal(t, key, "first")
+	second := seal(t, key, "second")
+	expectOpen(t, receiver, second, "second")
+	expectOpen(t, receiver, first, "first")
+
+	for _, message := range [][]byte{first, second} {
+		if _, err := receiver.Decrypt(message); !errors.Is(err, ErrDecryptionFailed) {
+			t.Fatalf("replay: err = %v, want %v", err, ErrDecryptionFailed)
+		}
+	}
+}
+
+func TestSenderKeyUnknownKey(t *testing.T) {
+	key, _ := newSenderPair(t)
+	_, other := newSenderPair(t)
+
+	if _, err := other.Decrypt(seal(t, key, "hello")); !errors.Is(err, ErrUnknownSenderKey) {
+		t.Fatalf("err = %v, want %v", err, ErrUnknownSenderKey)
+	}
+}
+
+func TestSenderKeyTampered(t *testing.T) {
+	key, receiver := newSenderPair(t)
+
+	message := seal(t, key, "hello")
+	for _, i := range []int{4, SenderKeyHeaderSize - 1, SenderKeyHeaderSize, len(message) - 1} {
+		tampered := bytes.Clone(message)
+		tampered[i] ^= 1
+		if _, err := receiver.Decrypt(tampered); err == nil {
+			t.Fatalf("byte %d flipped: Decrypt succeeded", i)
+		}
+	}
+	if _, err := receiver.Decrypt(message[:SenderKeyHeaderSize]); !errors.Is(err, ErrInvalidMessage) {
+		t.Fatalf("short message: err = %v, want %v", err, ErrInvalidMessage)
+	}
+	expectOpen(t, receiver, message, "hello")
+}
+
+func TestSenderKeyTooManySkipped(t *testing.T) {
+	key, receiver := newSenderPair(t)
+
+	for i := 0; i <= MaxSkip; i++ {
+		seal(t, key, "skipped")
+	}
+	if _, err := receiver.Decrypt(seal(t, key, "late")); !errors.Is(err, ErrTooManySkipped) {
+		t.Fatalf("err = %v, want %v", err, ErrTooManySkipped)
+	}
+
+	key, receiver = newSenderPair(t)
+	for i := 0; i < MaxSkip; i++ {
+		seal(t, key, "skipped")
+	}
+	expectOpen(t, receiver, seal(t, key, "edge"), "edge")
+}
@@ -0,0 +1,97 @@
+package roomauth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"hash"
+	"io"
+	"strings"
+)
+
+const (
+	InviteSeparator  = "#"
+	InviteSecretSize = 32
+
+	membershipContext   = "void-room-membership-v1"
+	announcementContext = "void-room-announcement-v1"
+)
+
+type MembershipKey []byte
+
+type Announcement struct {
+	RoomID       string
+	Username     string
+	PublicKey    []byte
+	IdentityKey  []byte
+	Capabilities []string
+}
+
+func NewInvite(roomID string) (string, error) {
+	secret := make([]byte, InviteSecretSize)
+	if _, err := io.ReadFull(rand.Reader, secret); err != nil {
+		return "", err
+	}
+	return roomID + InviteSeparator + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+func ParseInvite(invite string) (string, []byte, error) {
+	roomID, encoded, found := strings.Cut(strings.TrimSpace(invite), InviteSeparator)
+	if !found {
+		return "", nil, ErrMissingInviteSecret
+	}
+	secret, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(secret) != InviteSecretSize || roomID == "" {
+		return "", nil, ErrInvalidInvite
+	}
+	return roomID, secret, nil
+}
+
+func DeriveMembershipKey(roomID string, password string, secret []byte) MembershipKey {
+	if len(secret) != InviteSecretSize {
+		return nil
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	writeField(mac, []byte(membershipContext))
+	writeField(mac, []byte(roomID))
+	writeField(mac, []byte(password))
+	return mac.Sum(nil)
+}
+
+func (k MembershipKey) Sign(a Announcement) []byte {
+	mac := hmac.New(sha256.New, k)
+	writeField(mac, []byte(announcementContext))
+	writeField(mac, []byte(a.RoomID))
+	writeField(mac, []byte(a.Username))
+	writeField(mac, a.PublicKey)
+	writeField(mac, a.IdentityKey)
+	for _, capability := range a.Capabilities {
+		writeField(mac, []byte(capability))
+	}
+	return mac.Sum(nil)
+}
+
+func (k MembershipKey) Verify(a Announcement, proof []byte) bool {
+	return len(k) == sha256.Size && hmac.Equal(k.Sign(a), proof)
+}
+
+func writeField(h hash.Hash, field []byte) {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(field)))
+	h.Write(length[:])
+	h.Write(field)
+}
+
+type AuthError string
+
+func (e AuthError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrInvalidInvite       = AuthError("invalid invite")
+	ErrMissingInviteSecret = AuthError("invite has no secret, ask for the full invite")
+)
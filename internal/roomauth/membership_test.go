@@ -0,0 +1,127 @@
+package roomauth
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestInviteRoundTrip(t *testing.T) {
+	invite, err := NewInvite("room")
+	if err != nil {
+		t.Fatal(err)
+	}
+	roomID, secret, err := ParseInvite(" " + invite + "\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if roomID != "room" || len(secret) != InviteSecretSize {
+		t.Fatalf("ParseInvite = %q, %d byte secret", roomID, len(secret))
+	}
+
+	again, _ := NewInvite("room")
+	if again == invite {
+		t.Fatal("two invites share a secret")
+	}
+}
+
+func TestParseInviteErrors(t *testing.T) {
+	secret := strings.Repeat("A", 43)
+	tests := []struct {
+		invite string
+		err    error
+	}{
+		{"room", ErrMissingInviteSecret},
+		{"", ErrMissingInviteSecret},
+		{"room#", ErrInvalidInvite},
+		{"#" + secret, ErrInvalidInvite},
+		{"room#" + secret[1:], ErrInvalidInvite},
+		{"room#" + secret + "AA", ErrInvalidInvite},
+		{"room#" + secret[1:] + "!", ErrInvalidInvite},
+	}
+	for _, tt := range tests {
+		if _, _, err := ParseInvite(tt.invite); !errors.Is(err, tt.err) {
+			t.Errorf("ParseInvite(%q) err = %v, want %v", tt.invite, err, tt.err)
+		}
+	}
+}
+
+func TestDeriveMembershipKey(t *testing.T) {
+	secret := bytes.Repeat([]byte{1}, InviteSecretSize)
+	key := DeriveMembershipKey("room", "", secret)
+	if len(key) == 0 {
+		t.Fatal("no key derived")
+	}
+	if !bytes.Equal(key, DeriveMembershipKey("room", "", secret)) {
+		t.Fatal("key is not deterministic")
+	}
+
+	others := []MembershipKey{
+		DeriveMembershipKey("other", "", secret),
+		DeriveMembershipKey("room", "password", secret),
+		DeriveMembershipKey("room", "", bytes.Repeat([]byte{2}, InviteSecretSize)),
+	}
+	for i, other := range others {
+		if bytes.Equal(key, other) {
+			t.Errorf("key %d matches the original", i)
+		}
+	}
+
+	for _, size := range []int{0, 1, InviteSecretSize - 1, InviteSecretSize + 1} {
+		if DeriveMembershipKey("room", "", make([]byte, size)) != nil {
+			t.Errorf("%d byte secret derived a key", size)
+		}
+	}
+}
+
+func TestMembershipSignVerify(t *testing.T) {
+	key := DeriveMembershipKey("room", "", bytes.Repeat([]byte{1}, InviteSecretSize))
+	announcement := Announcement{
+		RoomID:       "room",
+		Username:     "alice",
+		PublicKey:    bytes.Repeat([]byte{3}, 32),
+		IdentityKey:  bytes.Repeat([]byte{4}, 32),
+		Capabilities: []string{"ratchet", "sender-keys"},
+	}
+	proof := key.Sign(announcement)
+	if !key.Verify(announcement, proof) {
+		t.Fatal("valid proof rejected")
+	}
+
+	tests := []struct {
+		name   string
+		change func(*Announcement)
+	}{
+		{"room", func(a *Announcement) { a.RoomID = "other" }},
+		{"username", func(a *Announcement) { a.Username = "mallory" }},
+		{"public key", func(a *Announcement) { a.PublicKey = bytes.Repeat([]byte{5}, 32) }},
+		{"identity key", func(a *Announcement) { a.IdentityKey = nil }},
+		{"capabilities dropped", func(a *Announcement) { a.Capabilities = a.Capabilities[:1] }},
+		{"capabilities merged", func(a *Announcement) { a.Capabilities = []string{"ratchetsender-keys"} }},
+		{"field boundary", func(a *Announcement) { a.RoomID, a.Username = "roomalice", "" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tampered := announcement
+			tampered.Capabilities = append([]string(nil), announcement.Capabilities...)
+			tt.change(&tampered)
+			if key.Verify(tampered, proof) {
+				t.Fatal("Verify accepted a tampered announcement")
+			}
+		})
+	}
+
+	if key.Verify(announcement, append([]byte{proof[0] ^ 1}, proof[1:]...)) {
+		t.Fatal("Verify accepted a tampered proof")
+	}
+	other := DeriveMembershipKey("room", "", bytes.Repeat([]byte{2}, InviteSecretSize))
+	if other.Verify(announcement, proof) {
+		t.Fatal("Verify accepted a proof from another invite")
+	}
+
+	var empty MembershipKey
+	if empty.Verify(announcement, empty.Sign(announcement)) {
+		t.Fatal("nil key verified its own proof")
+	}
+}
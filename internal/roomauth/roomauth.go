@@ -0,0 +1,76 @@
+package roomauth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"io"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	ChallengeSize = 32
+
+	saltContext  = "void-room-password-v1"
+	proofContext = "void-room-auth-v1"
+
+	argonTime    = 2
+	argonMemory  = 64 * 1024
+	argonThreads = 4
+)
+
+type Key struct {
+	privateKey ed25519.PrivateKey
+}
+
+func DeriveKey(roomID string, password string) *Key {
+	if password == "" {
+		return nil
+	}
+
+	salt := sha256.Sum256([]byte(saltContext + roomID))
+	seed := argon2.IDKey([]byte(password), salt[:], argonTime, argonMemory, argonThreads, ed25519.SeedSize)
+	return &Key{privateKey: ed25519.NewKeyFromSeed(seed)}
+}
+
+func (k *Key) Verifier() []byte {
+	if k == nil {
+		return nil
+	}
+	return k.privateKey.Public().(ed25519.PublicKey)
+}
+
+func (k *Key) Prove(roomID string, challenge []byte) []byte {
+	if k == nil {
+		return nil
+	}
+	return ed25519.Sign(k.privateKey, proofMessage(roomID, challenge))
+}
+
+func NewChallenge() ([]byte, error) {
+	challenge := make([]byte, ChallengeSize)
+	if _, err := io.ReadFull(rand.Reader, challenge); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+func Verify(verifier []byte, roomID string, challenge []byte, proof []byte) bool {
+	if len(verifier) != ed25519.PublicKeySize || len(challenge) != ChallengeSize {
+		return false
+	}
+	return ed25519.Verify(verifier, proofMessage(roomID, challenge), proof)
+}
+
+func ValidVerifier(verifier []byte) bool {
+	return len(verifier) == 0 || len(verifier) == ed25519.PublicKeySize
+}
+
+func proofMessage(roomID string, challenge []byte) []byte {
+	msg := make([]byte, 0, len(proofContext)+len(roomID)+1+len(challenge))
+	msg = append(msg, proofContext...)
+	msg = append(msg, roomID...)
+	msg = append(msg, 0)
+	return append(msg, challenge...)
+}
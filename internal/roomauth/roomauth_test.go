@@ -0,0 +1,62 @@
+package roomauth
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDeriveKeyEmptyPassword(t *testing.T) {
+	key := DeriveKey("room", "")
+	if key != nil {
+		t.Fatal("DeriveKey with an empty password returned a key")
+	}
+	if key.Verifier() != nil || key.Prove("room", make([]byte, ChallengeSize)) != nil {
+		t.Fatal("nil key produced a verifier or proof")
+	}
+}
+
+func TestProveVerify(t *testing.T) {
+	key := DeriveKey("room", "secret")
+	verifier := key.Verifier()
+	if !bytes.Equal(verifier, DeriveKey("room", "secret").Verifier()) {
+		t.Fatal("verifier is not deterministic")
+	}
+	if !ValidVerifier(verifier) || !ValidVerifier(nil) || ValidVerifier(verifier[1:]) {
+		t.Fatal("ValidVerifier disagrees with the verifier length")
+	}
+
+	challenge, err := NewChallenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof := key.Prove("room", challenge)
+	if !Verify(verifier, "room", challenge, proof) {
+		t.Fatal("valid proof rejected")
+	}
+
+	other, _ := NewChallenge()
+	tests := []struct {
+		name      string
+		verifier  []byte
+		roomID    string
+		challenge []byte
+		proof     []byte
+	}{
+		{"wrong password", DeriveKey("room", "guess").Verifier(), "room", challenge, proof},
+		{"same password other room", DeriveKey("other", "secret").Verifier(), "room", challenge, proof},
+		{"other room", verifier, "other", challenge, proof},
+		{"other challenge", verifier, "room", other, proof},
+		{"short challenge", verifier, "room", challenge[1:], key.Prove("room", challenge[1:])},
+		{"short verifier", verifier[1:], "room", challenge, proof},
+		{"no verifier", nil, "room", challenge, proof},
+		{"no proof", verifier, "room", challenge, nil},
+		{"tampered proof", verifier, "room", challenge, append([]byte{proof[0] ^ 1}, proof[1:]...)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if Verify(tt.verifier, tt.roomID, tt.challenge, tt.proof) {
+				t.Fatal("Verify accepted an invalid proof")
+			}
+		})
+	}
+}
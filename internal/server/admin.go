@@ -0,0 +1,188 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	HealthPath = "/healthz"
+	ReadyPath  = "/readyz"
+	StatusPath = "/status"
+	DrainPath  = "/drain"
+)
+
+type Status struct {
+	Version           string        `json:"version"`
+	Uptime            time.Duration `json:"uptime"`
+	Listening         bool          `json:"listening"`
+	Draining          bool          `json:"draining"`
+	Connections       int           `json:"connections"`
+	Rooms             int           `json:"rooms"`
+	SuspendedSessions int           `json:"suspended_sessions"`
+	Config            StatusConfig  `json:"config"`
+}
+
+type StatusConfig struct {
+	Address          string        `json:"address"`
+	WebSocketAddress string        `json:"websocket_address,omitempty"`
+	MetricsAddress   string        `json:"metrics_address,omitempty"`
+	TLS              bool          `json:"tls"`
+	MaxConnsPerIP    int           `json:"max_connections_per_ip"`
+	MaxRooms         int           `json:"max_rooms"`
+	MaxRoomSize      int           `json:"max_room_size"`
+	SendQueueSize    int           `json:"send_queue_size"`
+	SlowConsumer     string        `json:"slow_consumer"`
+	IdleTimeout      time.Duration `json:"idle_timeout"`
+	ResumeWindow     time.Duration `json:"resume_window"`
+	LogLevel         string        `json:"log_level"`
+}
+
+func (s *Server) Status() Status {
+	opts := s.options()
+
+	s.connsMu.Lock()
+	connections := len(s.conns)
+	s.connsMu.Unlock()
+
+	s.suspendedMu.Lock()
+	suspended := len(s.suspended)
+	s.suspendedMu.Unlock()
+
+	resumeWindow := opts.ResumeWindow
+	if resumeWindow < 0 {
+		resumeWindow = 0
+	}
+
+	return Status{
+		Version:           opts.Version,
+		Uptime:            time.Since(s.started),
+		Listening:         s.Listening(),
+		Draining:          s.Draining(),
+		Connections:       connections,
+		Rooms:             s.RoomCount(),
+		SuspendedSessions: suspended,
+		Config: StatusConfig{
+			Address:          opts.Address,
+			WebSocketAddress: opts.WebSocketAddress,
+			MetricsAddress:   opts.MetricsAddress,
+			TLS:              opts.TLSConfig != nil,
+			MaxConnsPerIP:    opts.MaxConnsPerIP,
+			MaxRooms:         opts.MaxRooms,
+			MaxRoomSize:      opts.MaxRoomSize,
+			SendQueueSize:    opts.SendQueueSize,
+			SlowConsumer:     string(opts.SlowConsumer),
+			IdleTimeout:      opts.IdleTimeout,
+			ResumeWindow:     resumeWindow,
+			LogLevel:         opts.LogLevel.String(),
+		},
+	}
+}
+
+func (s *Server) Listening() bool {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+	return !s.closing && len(s.listeners) > 0
+}
+
+func (s *Server) Ready() bool {
+	return s.Listening() && !s.Draining()
+}
+
+func (s *Server) HealthHandler() http.Handler {
+	return probe(s.Listening, "not listening")
+}
+
+func (s *Server) ReadyHandler() http.Handler {
+	return probe(s.Ready, "not ready")
+}
+
+func probe(check func() bool, failure string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if !check() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(failure + "\n"))
+			return
+		}
+		w.Write([]byte("ok\n"))
+	})
+}
+
+func (s *Server) StatusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.Status())
+	})
+}
+
+func (s *Server) DrainHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			s.Drain()
+			s.logger().Info("Draining, new rooms refused", "rooms", s.RoomCount())
+		case http.MethodDelete:
+			s.Undrain()
+			s.logger().Info("Drain cancelled, accepting new rooms")
+		default:
+			w.Header().Set("Allow", "POST, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.Status())
+	})
+}
+
+func (s *Server) StartAdmin() error {
+	path := s.options().AdminSocket
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return ErrAdminSocketInUse
+	}
+	os.Remove(path)
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		listener.Close()
+		return err
+	}
+	return s.ServeAdmin(context.Background(), listener)
+}
+
+func (s *Server) ServeAdmin(ctx context.Context, listener net.Listener) error {
+	mux := http.NewServeMux()
+	mux.Handle(StatusPath, s.StatusHandler())
+	mux.Handle(HealthPath, s.HealthHandler())
+	mux.Handle(ReadyPath, s.ReadyHandler())
+	mux.Handle(DrainPath, s.DrainHandler())
+
+	httpServer := &http.Server{Handler: mux}
+	if !s.addHTTPServer(httpServer, nil, nil) {
+		listener.Close()
+		return ErrServerClosed
+	}
+	stop := context.AfterFunc(ctx, func() {
+		httpServer.Close()
+	})
+	defer stop()
+
+	s.logger().Info("Admin socket listening", "path", listener.Addr().String())
+	err := httpServer.Serve(listener)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return ErrServerClosed
+	}
+	return err
+}
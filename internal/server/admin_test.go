@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDrainHandler(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := New(opts)
+	handler := s.DrainHandler()
+
+	request := func(method string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, DrainPath, nil))
+		return w
+	}
+
+	w := request(http.MethodPost)
+	var status Status
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || !status.Draining || !s.Draining() {
+		t.Fatalf("POST: code %d, draining %t", w.Code, status.Draining)
+	}
+
+	if w := request(http.MethodGet); w.Code != http.StatusMethodNotAllowed || !s.Draining() {
+		t.Fatalf("GET: code %d, draining %t", w.Code, s.Draining())
+	}
+
+	if w := request(http.MethodDelete); w.Code != http.StatusOK || s.Draining() {
+		t.Fatalf("DELETE: code %d, draining %t", w.Code, s.Draining())
+	}
+}
+
+func TestHealthFollowsListener(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := New(opts)
+	handler := s.HealthHandler()
+
+	health := func() int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, HealthPath, nil))
+		return w.Code
+	}
+	serve := func(ctx context.Context, listener net.Listener) <-chan error {
+		done := make(chan error, 1)
+		go func() { done <- s.Serve(ctx, listener) }()
+		deadline := time.Now().Add(time.Second)
+		for !s.Listening() && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		return done
+	}
+
+	if code := health(); code != http.StatusServiceUnavailable {
+		t.Fatalf("before Serve: code %d", code)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := serve(ctx, listener)
+	if code := health(); code != http.StatusOK {
+		t.Fatalf("while serving: code %d", code)
+	}
+	cancel()
+	<-done
+	if code := health(); code != http.StatusServiceUnavailable {
+		t.Fatalf("after cancel: code %d", code)
+	}
+
+	listener, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done = serve(context.Background(), listener)
+	if code := health(); code != http.StatusOK {
+		t.Fatalf("while serving again: code %d", code)
+	}
+	listener.Close()
+	if err := <-done; !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("Serve after close: %v", err)
+	}
+	if code := health(); code != http.StatusServiceUnavailable {
+		t.Fatalf("after listener closed: code %d", code)
+	}
+}
@@ -1,123 +1,408 @@
 package server
 
 import (
-	"net"
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"Void/internal/crypto"
+	"Void/internal/protocol"
+	"Void/internal/roomauth"
+	"Void/internal/transport"
 	"Void/proto/chatpb"
 
 	"google.golang.org/protobuf/proto"
 )
 
 type Connection struct {
-	ID        string
-	Username  string
-	PublicKey [32]byte
-	Conn      net.Conn
-	Room      *Room
-	send      chan []byte
-	server    *Server
+	ID           string
+	Username     string
+	PublicKey    [32]byte
+	IdentityKey  []byte
+	KeySignature []byte
+	Capabilities []string
+	Membership   []byte
+	Conn         transport.Conn
+	Room         *Room
+	ip           string
+	version      uint32
+	features     []string
+	challenge    []byte
+	handshake    time.Time
+	resumeToken  []byte
+	send         chan []byte
+	drain        chan struct{}
+	done         chan struct{}
+	readMu       sync.Mutex
+	readStopped  bool
+	limiter      tokenBucket
+	dropped      atomic.Uint64
+	pendingDrops atomic.Uint32
+	evicted      atomic.Bool
+	server       *Server
 }
 
-func newConnection(conn net.Conn, srv *Server) *Connection {
+func newConnection(conn transport.Conn, srv *Server) *Connection {
 	return &Connection{
-		ID:     generateID(),
-		Conn:   conn,
-		send:   make(chan []byte, 256),
-		server: srv,
+		ID:        generateID(),
+		Conn:      conn,
+		ip:        remoteIP(conn.RemoteAddr()),
+		handshake: time.Now().Add(srv.options().HandshakeTimeout),
+		send:      make(chan []byte, srv.options().SendQueueSize),
+		drain:     make(chan struct{}),
+		done:      make(chan struct{}),
+		server:    srv,
 	}
 }
 
-func (c *Connection) readPump() {
-	buf := make([]byte, 4096)
+func (c *Connection) readPump() error {
 	for {
-		n, err := c.Conn.Read(buf)
+		if !c.armReadDeadline() {
+			return ErrServerClosed
+		}
+		frame, err := c.Conn.ReadFrame()
 		if err != nil {
-			break
+			return err
 		}
 
 		msg := &chatpb.ClientMessage{}
-		if err := proto.Unmarshal(buf[:n], msg); err != nil {
+		err = proto.Unmarshal(frame, msg)
+		c.server.metrics.received(msg, len(frame), err)
+		if c.version == 0 {
+			if err := c.clientHello(msg); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			c.sendError(chatpb.ErrorCode_MALFORMED_FRAME, "Frame could not be decoded", "")
 			continue
 		}
 
 		switch payload := msg.Payload.(type) {
 		case *chatpb.ClientMessage_JoinRoom:
-			c.joinRoom(payload.JoinRoom)
+			c.requestRoom(payload.JoinRoom, false, msg.RequestId)
+		case *chatpb.ClientMessage_CreateRoom:
+			c.requestRoom(payload.CreateRoom, true, msg.RequestId)
 		case *chatpb.ClientMessage_SendMessage:
-			c.sendMessage(payload.SendMessage)
+			if !c.allow(c.Room, msg.RequestId) {
+				continue
+			}
+			c.sendMessage(payload.SendMessage, msg.RequestId)
 		case *chatpb.ClientMessage_LeaveRoom:
 			c.leaveRoom()
+		case *chatpb.ClientMessage_SenderKeyDistribution:
+			if !c.allow(nil, msg.RequestId) {
+				continue
+			}
+			c.distributeSenderKey(payload.SenderKeyDistribution, msg.RequestId)
+		case *chatpb.ClientMessage_Ping:
+			if c.Room == nil {
+				c.sendError(chatpb.ErrorCode_NOT_IN_ROOM, "Join a room before sending pings", msg.RequestId)
+				continue
+			}
+			c.pong(payload.Ping)
+		case *chatpb.ClientMessage_ClientHello:
+			c.sendError(chatpb.ErrorCode_BAD_REQUEST, "Hello already received", msg.RequestId)
+		case nil:
+			if len(msg.ProtoReflect().GetUnknown()) > 0 {
+				c.sendError(chatpb.ErrorCode_UNSUPPORTED, "Message type not supported", msg.RequestId)
+				continue
+			}
+			c.sendError(chatpb.ErrorCode_INVALID_MESSAGE, "Message has no payload", msg.RequestId)
+		default:
+			c.sendError(chatpb.ErrorCode_UNSUPPORTED, "Message type not supported", msg.RequestId)
 		}
 	}
 }
 
+func (c *Connection) allow(room *Room, requestID string) bool {
+	scope, wait := c.throttle(room)
+	if wait == 0 {
+		return true
+	}
+
+	if !c.supports(protocol.FeatureRateLimit) {
+		c.sendError(chatpb.ErrorCode_RATE_LIMITED, fmt.Sprintf("Rate limited, retry after %dms", retryAfterMs(wait)), requestID)
+		return false
+	}
+	c.sendRateLimited(scope, wait, requestID)
+	return false
+}
+
+func (c *Connection) sendRateLimited(scope chatpb.RateLimitScope, wait time.Duration, requestID string) {
+	msg := &chatpb.ServerMessage{
+		Payload: &chatpb.ServerMessage_RateLimited{
+			RateLimited: &chatpb.RateLimited{
+				Scope:        scope,
+				RetryAfterMs: retryAfterMs(wait),
+				RequestId:    requestID,
+			},
+		},
+	}
+	data, _ := proto.Marshal(msg)
+	c.sendData(data)
+}
+
+func retryAfterMs(wait time.Duration) uint32 {
+	return uint32((wait + time.Millisecond - 1) / time.Millisecond)
+}
+
+func (c *Connection) throttle(room *Room) (chatpb.RateLimitScope, time.Duration) {
+	s := c.server
+	opts := s.options()
+	now := time.Now()
+
+	scope := chatpb.RateLimitScope_CONNECTION
+	wait := c.limiter.take(opts.ConnectionRate, now)
+	if wait == 0 && opts.IPRate.enabled() {
+		scope = chatpb.RateLimitScope_IP
+		wait = s.ipLimiters.get(c.ip, opts.IPRate, now).take(opts.IPRate, now)
+	}
+	if wait == 0 && room != nil {
+		scope = chatpb.RateLimitScope_ROOM
+		wait = room.limiter.take(opts.RoomRate, now)
+	}
+	if wait > 0 {
+		s.metrics.rateLimited.With(scopeLabel(scope)).Inc()
+		s.logger().Debug("Rate limited", s.redactor.IP(c.ip), "scope", scopeLabel(scope), "retry_after", wait)
+	}
+	return scope, wait
+}
+
+func (c *Connection) armReadDeadline() bool {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+	if c.readStopped {
+		return false
+	}
+	c.Conn.SetReadDeadline(c.readDeadline())
+	return true
+}
+
+func (c *Connection) stopReading() {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+	c.readStopped = true
+	c.Conn.SetReadDeadline(time.Now())
+}
+
+func (c *Connection) readDeadline() time.Time {
+	if c.Room == nil {
+		return c.handshake
+	}
+	return time.Now().Add(c.server.options().IdleTimeout)
+}
+
 func (c *Connection) writePump() {
-	for message := range c.send {
-		if _, err := c.Conn.Write(message); err != nil {
+	defer c.server.writers.Done()
+	for {
+		select {
+		case message := <-c.send:
+			err := c.write(message)
+			if err == nil && len(c.send) == 0 {
+				err = c.notifyDrops()
+			}
+			if err != nil {
+				c.Conn.Close()
+				return
+			}
+		case <-c.drain:
+			c.flush()
+			c.Conn.Close()
+			return
+		case <-c.done:
 			return
 		}
 	}
 }
 
-func (c *Connection) sendData(data []byte) {
-	select {
-	case c.send <- data:
-	default:
+func (c *Connection) write(message []byte) error {
+	c.Conn.SetWriteDeadline(time.Now().Add(c.server.options().WriteTimeout))
+	if err := c.Conn.WriteFrame(message); err != nil {
+		return err
 	}
+	c.server.metrics.sent(message)
+	return nil
 }
 
-func (c *Connection) joinRoom(req *chatpb.RoomRequest) {
+func (c *Connection) flush() {
+	for {
+		select {
+		case message := <-c.send:
+			if err := c.write(message); err != nil {
+				return
+			}
+		default:
+			c.notifyDrops()
+			return
+		}
+	}
+}
+
+func (c *Connection) pong(ping *chatpb.Ping) {
+	msg := &chatpb.ServerMessage{
+		Payload: &chatpb.ServerMessage_Pong{
+			Pong: &chatpb.Pong{
+				Nonce: ping.Nonce,
+			},
+		},
+	}
+	data, _ := proto.Marshal(msg)
+	c.sendData(data)
+}
+
+func (c *Connection) requestRoom(req *chatpb.RoomRequest, create bool, requestID string) {
+	if c.Room != nil {
+		c.sendError(chatpb.ErrorCode_ALREADY_IN_ROOM, "Already in a room", requestID)
+		return
+	}
+	if scope, wait := c.throttle(nil); wait > 0 {
+		if c.supports(protocol.FeatureRateLimit) {
+			c.sendRateLimited(scope, wait, requestID)
+		} else {
+			c.rejectJoin(chatpb.ErrorCode_RATE_LIMITED, fmt.Sprintf("Rate limited, retry after %dms", retryAfterMs(wait)))
+		}
+		return
+	}
+	c.joinRoom(req, create)
+}
+
+func (c *Connection) joinRoom(req *chatpb.RoomRequest, create bool) {
 	copy(c.PublicKey[:], req.PublicKey)
+	c.IdentityKey = req.IdentityKey
+	c.KeySignature = req.KeySignature
+	c.Capabilities = req.Capabilities
+	c.Membership = req.MembershipProof
 	c.Username = req.Username
 
 	s := c.server
 	s.roomsMu.Lock()
 	room, exists := s.rooms[req.RoomId]
 
+	opts := s.options()
+	if exists && create {
+		s.roomsMu.Unlock()
+		c.rejectJoin(chatpb.ErrorCode_ROOM_EXISTS, "Room already exists")
+		return
+	}
 	if !exists {
-		room = NewRoom(req.RoomId, req.Password)
+		if !create && c.version >= protocol.VersionCreateRoom {
+			s.roomsMu.Unlock()
+			c.rejectJoin(chatpb.ErrorCode_ROOM_NOT_FOUND, "Room not found")
+			return
+		}
+		if s.draining.Load() {
+			s.roomsMu.Unlock()
+			c.rejectJoin(chatpb.ErrorCode_DRAINING, "Server is draining")
+			return
+		}
+		if opts.MaxRooms > 0 && len(s.rooms) >= opts.MaxRooms {
+			s.roomsMu.Unlock()
+			c.rejectJoin(chatpb.ErrorCode_TOO_MANY_ROOMS, "Too many rooms")
+			return
+		}
+		if !roomauth.ValidVerifier(req.PasswordVerifier) {
+			s.roomsMu.Unlock()
+			c.rejectJoin(chatpb.ErrorCode_INVALID_VERIFIER, "Invalid password verifier")
+			return
+		}
+		room = NewRoom(req.RoomId, req.PasswordVerifier)
 		s.rooms[req.RoomId] = room
 	} else {
-		if room.Password != "" && room.Password != req.Password {
+		if room.HasPassword() && !roomauth.Verify(room.Verifier, req.RoomId, c.challenge, req.PasswordProof) {
 			s.roomsMu.Unlock()
-			roomResp := &chatpb.RoomResponse{
-				Success: false,
-				Message: "Invalid password",
-				Peers:   nil,
-				UserId:  "",
-			}
-			response := &chatpb.ServerMessage{
-				Payload: &chatpb.ServerMessage_RoomResponse{
-					RoomResponse: roomResp,
-				},
-			}
-			data, _ := proto.Marshal(response)
-			c.sendData(data)
+			c.rejectJoin(chatpb.ErrorCode_INVALID_PASSWORD, "Invalid password")
 			return
 		}
 	}
 	s.roomsMu.Unlock()
 
-	room.AddClient(c)
+	if previous := s.takeSuspended(req.ResumeToken, room); previous != nil {
+		c.resume(previous)
+		return
+	}
+
+	if c.supports(protocol.FeatureResume) {
+		c.resumeToken = newResumeToken()
+	}
+	if !room.TryAddClient(c, opts.MaxRoomSize) {
+		s.dropIfEmpty(room)
+		c.rejectJoin(chatpb.ErrorCode_ROOM_FULL, "Room is full")
+		return
+	}
 	c.Room = room
+	s.logger().Info("Room joined", s.redactor.Room(room.ID), s.redactor.IP(c.ip), "created", !exists, "members", room.ClientCount())
+
+	c.sendRoomResponse()
+
+	peerJoined := &chatpb.ServerMessage{
+		Payload: &chatpb.ServerMessage_PeerJoined{
+			PeerJoined: &chatpb.PeerJoined{
+				UserId:          c.ID,
+				Username:        c.Username,
+				PublicKey:       c.PublicKey[:],
+				IdentityKey:     c.IdentityKey,
+				KeySignature:    c.KeySignature,
+				Capabilities:    c.Capabilities,
+				MembershipProof: c.Membership,
+			},
+		},
+	}
+	peerJoinedData, _ := proto.Marshal(peerJoined)
+	room.Broadcast(peerJoinedData, c.ID)
+}
+
+func (c *Connection) resume(previous *Connection) {
+	c.ID = previous.ID
+	c.PublicKey = previous.PublicKey
+	c.IdentityKey = previous.IdentityKey
+	c.KeySignature = previous.KeySignature
+	c.Capabilities = previous.Capabilities
+	c.Membership = previous.Membership
+	c.Username = previous.Username
+	if c.supports(protocol.FeatureResume) {
+		c.resumeToken = newResumeToken()
+	}
+	c.Room = previous.Room
+	c.Room.AddClient(c)
+	c.pendingDrops.Add(previous.pendingDrops.Swap(0))
+	c.server.logger().Info("Session resumed", c.server.redactor.Room(c.Room.ID), c.server.redactor.IP(c.ip))
 
-	peers := room.GetClientsExcept(c.ID)
+	for pending := true; pending; {
+		select {
+		case data := <-previous.send:
+			c.sendData(data)
+		default:
+			pending = false
+		}
+	}
+
+	c.sendRoomResponse()
+}
+
+func (c *Connection) sendRoomResponse() {
+	peers := c.Room.GetClientsExcept(c.ID)
 	peerList := make([]*chatpb.Peer, 0, len(peers))
 	for _, peer := range peers {
 		peerList = append(peerList, &chatpb.Peer{
-			UserId:    peer.ID,
-			Username:  peer.Username,
-			PublicKey: peer.PublicKey[:],
+			UserId:          peer.ID,
+			Username:        peer.Username,
+			PublicKey:       peer.PublicKey[:],
+			IdentityKey:     peer.IdentityKey,
+			KeySignature:    peer.KeySignature,
+			Capabilities:    peer.Capabilities,
+			MembershipProof: peer.Membership,
 		})
 	}
 
 	roomResp := &chatpb.RoomResponse{
-		Success: true,
-		Message: "Joined room",
-		Peers:   peerList,
-		UserId:  c.ID,
+		Success:     true,
+		Message:     "Joined room",
+		Peers:       peerList,
+		UserId:      c.ID,
+		ResumeToken: c.resumeToken,
 	}
 	response := &chatpb.ServerMessage{
 		Payload: &chatpb.ServerMessage_RoomResponse{
@@ -127,76 +412,174 @@ func (c *Connection) joinRoom(req *chatpb.RoomRequest) {
 
 	data, _ := proto.Marshal(response)
 	c.sendData(data)
+}
+
+func newResumeToken() []byte {
+	token := make([]byte, 32)
+	rand.Read(token)
+	return token
+}
+
+func (c *Connection) rejectJoin(code chatpb.ErrorCode, message string) {
+	c.server.metrics.joinFailures.With(errorLabel(code)).Inc()
+	c.server.logger().Info("Join rejected", c.server.redactor.IP(c.ip), "reason", errorLabel(code))
+
+	roomResp := &chatpb.RoomResponse{
+		Success: false,
+		Message: message,
+		Peers:   nil,
+		UserId:  "",
+		Code:    code,
+	}
+	response := &chatpb.ServerMessage{
+		Payload: &chatpb.ServerMessage_RoomResponse{
+			RoomResponse: roomResp,
+		},
+	}
+	data, _ := proto.Marshal(response)
+	c.sendData(data)
+}
 
-	peerJoined := &chatpb.ServerMessage{
-		Payload: &chatpb.ServerMessage_PeerJoined{
-			PeerJoined: &chatpb.PeerJoined{
-				UserId:    c.ID,
-				Username:  c.Username,
-				PublicKey: c.PublicKey[:],
+func (c *Connection) sendChallenge() error {
+	challenge, err := roomauth.NewChallenge()
+	if err != nil {
+		return err
+	}
+	c.challenge = challenge
+
+	msg := &chatpb.ServerMessage{
+		Payload: &chatpb.ServerMessage_AuthChallenge{
+			AuthChallenge: &chatpb.AuthChallenge{
+				Nonce: challenge,
 			},
 		},
 	}
-	peerJoinedData, _ := proto.Marshal(peerJoined)
-	room.Broadcast(peerJoinedData, c.ID)
+	data, _ := proto.Marshal(msg)
+	c.sendData(data)
+	return nil
 }
 
-func (c *Connection) sendMessage(msg *chatpb.SendMessage) {
+func (c *Connection) sendMessage(msg *chatpb.SendMessage, requestID string) {
 	if c.Room == nil {
+		c.sendError(chatpb.ErrorCode_NOT_IN_ROOM, "Not in a room", requestID)
 		return
 	}
-
-	encryptedMessages, err := crypto.UnpackEncryptedMessages(msg.EncryptedContent)
-	if err != nil {
+	if len(msg.GroupCiphertext) == 0 && len(msg.Envelopes) == 0 {
+		c.sendError(chatpb.ErrorCode_INVALID_MESSAGE, "Message has no ciphertext", requestID)
 		return
 	}
 
-	peers := c.Room.GetClientsExcept(c.ID)
+	msgID := generateID()
+	timestamp := time.Now().UnixNano()
 
-	if len(encryptedMessages) != len(peers) {
-		return
+	build := func(ciphertext []byte, suite chatpb.CipherSuite) *chatpb.ServerMessage {
+		return &chatpb.ServerMessage{
+			Payload: &chatpb.ServerMessage_Message{
+				Message: &chatpb.ReceiveMessage{
+					Id:               msgID,
+					UserId:           c.ID,
+					Username:         c.Username,
+					EncryptedContent: ciphertext,
+					Timestamp:        timestamp,
+					Suite:            suite,
+				},
+			},
+		}
 	}
 
-	msgID := generateID()
-	username := c.Username
-	userID := c.ID
-	timestamp := time.Now().UnixNano()
+	var results []*chatpb.DeliveryResult
+	if len(msg.GroupCiphertext) > 0 {
+		results = c.broadcastCiphertext(msg.GroupCiphertext, build)
+	} else {
+		results = c.routeEnvelopes(msg.Envelopes, build)
+	}
 
-	peerList := make([]*Connection, 0, len(peers))
-	for _, peer := range peers {
-		peerList = append(peerList, peer)
+	report := &chatpb.ServerMessage{
+		Payload: &chatpb.ServerMessage_DeliveryReport{
+			DeliveryReport: &chatpb.DeliveryReport{
+				MessageId: msgID,
+				Results:   results,
+			},
+		},
 	}
+	data, _ := proto.Marshal(report)
+	c.sendData(data)
+}
 
-	if len(encryptedMessages) != len(peerList) {
+func (c *Connection) distributeSenderKey(msg *chatpb.SenderKeyDistribution, requestID string) {
+	if c.Room == nil {
+		c.sendError(chatpb.ErrorCode_NOT_IN_ROOM, "Not in a room", requestID)
 		return
 	}
 
-	for i, peer := range peerList {
-		if i >= len(encryptedMessages) {
-			break
+	c.routeEnvelopes(msg.Envelopes, func(ciphertext []byte, suite chatpb.CipherSuite) *chatpb.ServerMessage {
+		return &chatpb.ServerMessage{
+			Payload: &chatpb.ServerMessage_SenderKey{
+				SenderKey: &chatpb.SenderKeyMessage{
+					UserId:           c.ID,
+					EncryptedContent: ciphertext,
+					Suite:            suite,
+				},
+			},
 		}
+	})
+}
 
-		receiveMsg := &chatpb.ReceiveMessage{
-			Id:               msgID,
-			UserId:           userID,
-			Username:         username,
-			EncryptedContent: crypto.PackEncryptedMessages([][]byte{encryptedMessages[i]}),
-			Timestamp:        timestamp,
-		}
+func (c *Connection) routeEnvelopes(envelopes []*chatpb.Envelope, build func([]byte, chatpb.CipherSuite) *chatpb.ServerMessage) []*chatpb.DeliveryResult {
+	peers := c.Room.GetClientsExcept(c.ID)
 
-		serverMsg := &chatpb.ServerMessage{
-			Payload: &chatpb.ServerMessage_Message{
-				Message: receiveMsg,
-			},
+	results := make([]*chatpb.DeliveryResult, 0, len(envelopes))
+	for _, envelope := range envelopes {
+		var status chatpb.DeliveryStatus
+		peer, exists := peers[envelope.RecipientUserId]
+		if !exists {
+			status = chatpb.DeliveryStatus_UNKNOWN_RECIPIENT
+			if c.Room.HasDeparted(envelope.RecipientUserId) {
+				status = chatpb.DeliveryStatus_DEPARTED
+			}
+		} else {
+			data, _ := proto.Marshal(build(envelope.Ciphertext, envelope.Suite))
+			status = peer.deliver(data)
 		}
-		data, _ := proto.Marshal(serverMsg)
-		peer.sendData(data)
+
+		results = append(results, &chatpb.DeliveryResult{
+			RecipientUserId: envelope.RecipientUserId,
+			Status:          status,
+		})
+	}
+	return results
+}
+
+func (c *Connection) broadcastCiphertext(ciphertext []byte, build func([]byte, chatpb.CipherSuite) *chatpb.ServerMessage) []*chatpb.DeliveryResult {
+	peers := c.Room.GetClientsExcept(c.ID)
+	data, _ := proto.Marshal(build(ciphertext, chatpb.CipherSuite_SENDER_KEY))
+
+	results := make([]*chatpb.DeliveryResult, 0, len(peers))
+	for id, peer := range peers {
+		results = append(results, &chatpb.DeliveryResult{
+			RecipientUserId: id,
+			Status:          peer.deliver(data),
+		})
+	}
+	return results
+}
+
+func (c *Connection) deliver(data []byte) chatpb.DeliveryStatus {
+	if !c.sendData(data) {
+		return chatpb.DeliveryStatus_DROPPED
+	}
+	select {
+	case <-c.done:
+		return chatpb.DeliveryStatus_QUEUED
+	default:
 	}
+	return chatpb.DeliveryStatus_DELIVERED
 }
 
 func (c *Connection) leaveRoom() {
 	if c.Room == nil {
 		return
 	}
-	c.server.removeClient(c)
+	c.server.removeClient(c, chatpb.LeaveReason_LEFT)
+	c.handshake = time.Now().Add(c.server.options().HandshakeTimeout)
 }
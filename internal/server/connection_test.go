@@ -0,0 +1,153 @@
+package server
+
+import (
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"Void/internal/protocol"
+	"Void/proto/chatpb"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+type scriptedConn struct {
+	frames  [][]byte
+	written [][]byte
+	closed  bool
+}
+
+func (c *scriptedConn) ReadFrame() ([]byte, error) {
+	if len(c.frames) == 0 {
+		return nil, io.EOF
+	}
+	frame := c.frames[0]
+	c.frames = c.frames[1:]
+	return frame, nil
+}
+
+func (c *scriptedConn) WriteFrame(data []byte) error {
+	c.written = append(c.written, data)
+	return nil
+}
+
+func (c *scriptedConn) SetReadDeadline(time.Time) error  { return nil }
+func (c *scriptedConn) SetWriteDeadline(time.Time) error { return nil }
+func (c *scriptedConn) RemoteAddr() net.Addr             { return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)} }
+func (c *scriptedConn) Close() error {
+	c.closed = true
+	return nil
+}
+
+func runReadPump(t *testing.T, opts Options, features []string, messages ...[]byte) []*chatpb.ServerMessage {
+	t.Helper()
+	opts.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := New(opts)
+	c := newConnection(&scriptedConn{frames: messages}, s)
+	c.version = protocol.Version
+	c.features = features
+
+	if err := c.readPump(); err != io.EOF {
+		t.Fatalf("readPump: %v", err)
+	}
+	close(c.send)
+
+	var replies []*chatpb.ServerMessage
+	for data := range c.send {
+		msg := &chatpb.ServerMessage{}
+		if err := proto.Unmarshal(data, msg); err != nil {
+			t.Fatal(err)
+		}
+		replies = append(replies, msg)
+	}
+	return replies
+}
+
+func marshal(t *testing.T, msg *chatpb.ClientMessage) []byte {
+	t.Helper()
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func expectServerError(t *testing.T, msg *chatpb.ServerMessage, code chatpb.ErrorCode, requestID string) {
+	t.Helper()
+	serverError := msg.GetServerError()
+	if serverError == nil {
+		t.Fatalf("got %v, want a server error", msg)
+	}
+	if serverError.Code != code || serverError.RequestId != requestID {
+		t.Fatalf("got %s for %q, want %s for %q", serverError.Code, serverError.RequestId, code, requestID)
+	}
+}
+
+func TestReadPumpRejectsUnexpectedMessages(t *testing.T) {
+	unknown := protowire.AppendTag(nil, 99, protowire.BytesType)
+	unknown = protowire.AppendBytes(unknown, []byte("future"))
+	unknown = append(unknown, marshal(t, &chatpb.ClientMessage{RequestId: "3"})...)
+
+	replies := runReadPump(t, DefaultOptions(), nil,
+		marshal(t, &chatpb.ClientMessage{
+			RequestId: "1",
+			Payload:   &chatpb.ClientMessage_ClientHello{ClientHello: &chatpb.ClientHello{}},
+		}),
+		marshal(t, &chatpb.ClientMessage{RequestId: "2"}),
+		unknown,
+	)
+	if len(replies) != 3 {
+		t.Fatalf("%d replies, want 3", len(replies))
+	}
+	expectServerError(t, replies[0], chatpb.ErrorCode_BAD_REQUEST, "1")
+	expectServerError(t, replies[1], chatpb.ErrorCode_INVALID_MESSAGE, "2")
+	expectServerError(t, replies[2], chatpb.ErrorCode_UNSUPPORTED, "3")
+}
+
+func TestReadPumpReportsRateLimits(t *testing.T) {
+	opts := DefaultOptions()
+	opts.ConnectionRate = RateLimit{PerSecond: 0.001, Burst: 1}
+	distribute := func(requestID string) []byte {
+		return marshal(t, &chatpb.ClientMessage{
+			RequestId: requestID,
+			Payload:   &chatpb.ClientMessage_SenderKeyDistribution{SenderKeyDistribution: &chatpb.SenderKeyDistribution{}},
+		})
+	}
+
+	replies := runReadPump(t, opts, nil, distribute("1"), distribute("2"))
+	last := replies[len(replies)-1]
+	expectServerError(t, last, chatpb.ErrorCode_RATE_LIMITED, "2")
+
+	replies = runReadPump(t, opts, []string{protocol.FeatureRateLimit}, distribute("1"), distribute("2"))
+	last = replies[len(replies)-1]
+	if last.GetRateLimited() == nil {
+		t.Fatalf("got %v, want a rate limit notice", last)
+	}
+}
+
+func TestRequestRoomReportsRateLimits(t *testing.T) {
+	opts := DefaultOptions()
+	opts.ConnectionRate = RateLimit{PerSecond: 0.001, Burst: 1}
+	join := func(requestID string) []byte {
+		return marshal(t, &chatpb.ClientMessage{
+			RequestId: requestID,
+			Payload:   &chatpb.ClientMessage_JoinRoom{JoinRoom: &chatpb.RoomRequest{RoomId: "room"}},
+		})
+	}
+
+	replies := runReadPump(t, opts, []string{protocol.FeatureRateLimit}, join("1"), join("2"))
+	limited := replies[len(replies)-1].GetRateLimited()
+	if limited == nil || limited.RequestId != "2" || limited.RetryAfterMs == 0 {
+		t.Fatalf("got %v, want a rate limit notice for the join", replies[len(replies)-1])
+	}
+
+	replies = runReadPump(t, opts, nil, join("1"), join("2"))
+	resp := replies[len(replies)-1].GetRoomResponse()
+	if resp == nil || resp.Code != chatpb.ErrorCode_RATE_LIMITED || !strings.Contains(resp.Message, "retry after") {
+		t.Fatalf("got %v, want a rate limited join with a retry hint", replies[len(replies)-1])
+	}
+}
@@ -0,0 +1,29 @@
+package server
+
+import (
+	"strings"
+
+	"Void/proto/chatpb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func errorLabel(code chatpb.ErrorCode) string {
+	return strings.ToLower(code.String())
+}
+
+func (c *Connection) sendError(code chatpb.ErrorCode, detail string, requestID string) {
+	c.server.logger().Debug("Request rejected", c.server.redactor.IP(c.ip), "reason", errorLabel(code))
+
+	msg := &chatpb.ServerMessage{
+		Payload: &chatpb.ServerMessage_ServerError{
+			ServerError: &chatpb.ServerError{
+				Code:      code,
+				Detail:    detail,
+				RequestId: requestID,
+			},
+		},
+	}
+	data, _ := proto.Marshal(msg)
+	c.sendData(data)
+}
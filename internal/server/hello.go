@@ -0,0 +1,88 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"Void/internal/protocol"
+	"Void/proto/chatpb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func (s *Server) features() []string {
+	features := []string{
+		protocol.FeatureFraming,
+		protocol.FeatureRatchet,
+		protocol.FeatureSenderKeys,
+		protocol.FeatureRateLimit,
+		protocol.FeatureDropNotice,
+		protocol.FeatureMembership,
+	}
+	if s.options().ResumeWindow > 0 {
+		features = append(features, protocol.FeatureResume)
+	}
+	return features
+}
+
+func (c *Connection) sendHello() {
+	opts := c.server.options()
+	msg := &chatpb.ServerMessage{
+		Payload: &chatpb.ServerMessage_ServerHello{
+			ServerHello: &chatpb.ServerHello{
+				MinVersion:   protocol.MinVersion,
+				MaxVersion:   protocol.Version,
+				Features:     c.server.features(),
+				MaxFrameSize: uint32(opts.MaxFrameSize),
+				MaxRoomSize:  uint32(opts.MaxRoomSize),
+			},
+		},
+	}
+	data, _ := proto.Marshal(msg)
+	c.sendData(data)
+}
+
+func (c *Connection) clientHello(msg *chatpb.ClientMessage) error {
+	hello := msg.GetClientHello()
+	if hello == nil {
+		return c.rejectHello(chatpb.HelloError_HELLO_REQUIRED, "expected a ClientHello as the first frame")
+	}
+	if hello.ProtocolVersion < protocol.MinVersion || hello.ProtocolVersion > protocol.Version {
+		return c.rejectHello(chatpb.HelloError_UNSUPPORTED_VERSION,
+			fmt.Sprintf("server speaks protocol versions %d to %d, client asked for %d", protocol.MinVersion, protocol.Version, hello.ProtocolVersion))
+	}
+	features := c.server.features()
+	if missing := protocol.Missing(features, hello.RequiredFeatures); len(missing) > 0 {
+		return c.rejectHello(chatpb.HelloError_UNSUPPORTED_FEATURE,
+			"server does not support "+strings.Join(missing, ", "))
+	}
+
+	c.version = hello.ProtocolVersion
+	c.features = protocol.Common(hello.Features, features)
+	return c.sendChallenge()
+}
+
+func (c *Connection) rejectHello(code chatpb.HelloError, detail string) error {
+	c.server.metrics.handshakeFailures.With(helloLabel(code)).Inc()
+	c.server.logger().Info("Handshake rejected", c.server.redactor.IP(c.ip), "reason", helloLabel(code))
+
+	msg := &chatpb.ServerMessage{
+		Payload: &chatpb.ServerMessage_HelloRejected{
+			HelloRejected: &chatpb.HelloRejected{
+				Code:   code,
+				Detail: detail,
+			},
+		},
+	}
+	data, _ := proto.Marshal(msg)
+	c.write(data)
+	return ErrHandshakeFailed
+}
+
+func helloLabel(code chatpb.HelloError) string {
+	return strings.ToLower(code.String())
+}
+
+func (c *Connection) supports(feature string) bool {
+	return protocol.Has(c.features, feature)
+}
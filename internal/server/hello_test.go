@@ -0,0 +1,63 @@
+package server
+
+import (
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"Void/internal/protocol"
+	"Void/proto/chatpb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func runHello(t *testing.T, hello *chatpb.ClientHello) (*Connection, *scriptedConn, error) {
+	t.Helper()
+	opts := DefaultOptions()
+	opts.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	conn := &scriptedConn{frames: [][]byte{marshal(t, &chatpb.ClientMessage{
+		Payload: &chatpb.ClientMessage_ClientHello{ClientHello: hello},
+	})}}
+	c := newConnection(conn, New(opts))
+	return c, conn, c.readPump()
+}
+
+func TestHelloIgnoresUnknownFeatures(t *testing.T) {
+	c, _, err := runHello(t, &chatpb.ClientHello{
+		ProtocolVersion:  protocol.Version,
+		Features:         []string{protocol.FeatureRatchet, "teleport", protocol.FeatureRateLimit},
+		RequiredFeatures: []string{protocol.FeatureMembership},
+	})
+	if err != io.EOF {
+		t.Fatalf("readPump: %v", err)
+	}
+	if want := []string{protocol.FeatureRatchet, protocol.FeatureRateLimit}; !reflect.DeepEqual(c.features, want) {
+		t.Fatalf("features = %v, want %v", c.features, want)
+	}
+	if c.challenge == nil {
+		t.Fatal("no challenge after an accepted hello")
+	}
+}
+
+func TestHelloRejectsMissingRequiredFeature(t *testing.T) {
+	_, conn, err := runHello(t, &chatpb.ClientHello{
+		ProtocolVersion:  protocol.Version,
+		Features:         []string{"teleport"},
+		RequiredFeatures: []string{protocol.FeatureMembership, "teleport"},
+	})
+	if err != ErrHandshakeFailed {
+		t.Fatalf("readPump: %v", err)
+	}
+	if len(conn.written) != 1 {
+		t.Fatalf("%d frames written, want 1", len(conn.written))
+	}
+	msg := &chatpb.ServerMessage{}
+	if err := proto.Unmarshal(conn.written[0], msg); err != nil {
+		t.Fatal(err)
+	}
+	rejected := msg.GetHelloRejected()
+	if rejected == nil || rejected.Code != chatpb.HelloError_UNSUPPORTED_FEATURE {
+		t.Fatalf("got %v, want an unsupported feature rejection", msg)
+	}
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"Void/internal/protocol"
+	"Void/proto/chatpb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func newTestServer() *Server {
+	opts := DefaultOptions()
+	opts.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	return New(opts)
+}
+
+func requestRoomAs(t *testing.T, s *Server, version uint32, create bool, roomID string) *chatpb.RoomResponse {
+	t.Helper()
+	req := &chatpb.RoomRequest{RoomId: roomID, Username: "alice"}
+	msg := &chatpb.ClientMessage{Payload: &chatpb.ClientMessage_JoinRoom{JoinRoom: req}}
+	if create {
+		msg.Payload = &chatpb.ClientMessage_CreateRoom{CreateRoom: req}
+	}
+
+	c := newConnection(&scriptedConn{frames: [][]byte{marshal(t, msg)}}, s)
+	c.version = version
+	if err := c.readPump(); err != io.EOF {
+		t.Fatalf("readPump: %v", err)
+	}
+	for len(c.send) > 0 {
+		data := <-c.send
+		reply := &chatpb.ServerMessage{}
+		if err := proto.Unmarshal(data, reply); err != nil {
+			t.Fatal(err)
+		}
+		if resp := reply.GetRoomResponse(); resp != nil {
+			return resp
+		}
+	}
+	t.Fatal("no room response")
+	return nil
+}
+
+func TestJoinAndCreateRoom(t *testing.T) {
+	s := newTestServer()
+
+	if resp := requestRoomAs(t, s, protocol.Version, false, "room"); resp.Success || resp.Code != chatpb.ErrorCode_ROOM_NOT_FOUND {
+		t.Fatalf("join before create: %v", resp)
+	}
+	if s.RoomCount() != 0 {
+		t.Fatal("join created a room")
+	}
+	if resp := requestRoomAs(t, s, protocol.Version, true, "room"); !resp.Success {
+		t.Fatalf("create: %v", resp)
+	}
+	if resp := requestRoomAs(t, s, protocol.Version, true, "room"); resp.Success || resp.Code != chatpb.ErrorCode_ROOM_EXISTS {
+		t.Fatalf("second create: %v", resp)
+	}
+	if resp := requestRoomAs(t, s, protocol.Version, false, "room"); !resp.Success || len(resp.Peers) != 1 {
+		t.Fatalf("join after create: %v", resp)
+	}
+}
+
+func TestLegacyJoinCreatesRoom(t *testing.T) {
+	s := newTestServer()
+
+	if resp := requestRoomAs(t, s, protocol.VersionCreateRoom-1, false, "room"); !resp.Success {
+		t.Fatalf("legacy join: %v", resp)
+	}
+	if s.RoomCount() != 1 {
+		t.Fatal("legacy join did not create the room")
+	}
+	if resp := requestRoomAs(t, s, protocol.VersionCreateRoom-1, false, "room"); !resp.Success || len(resp.Peers) != 1 {
+		t.Fatalf("legacy join of an existing room: %v", resp)
+	}
+}
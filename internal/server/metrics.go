@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"Void/internal/metrics"
+	"Void/proto/chatpb"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const MetricsPath = "/metrics"
+
+var roomSizeBuckets = []float64{1, 2, 3, 5, 10, 20, 50, 100}
+
+var (
+	clientPayloads = (&chatpb.ClientMessage{}).ProtoReflect().Descriptor().Oneofs().ByName("payload")
+	serverPayloads = (&chatpb.ServerMessage{}).ProtoReflect().Descriptor().Oneofs().ByName("payload")
+)
+
+type serverMetrics struct {
+	registry          *metrics.Registry
+	framesIn          *metrics.CounterVec
+	framesOut         *metrics.CounterVec
+	bytes             *metrics.CounterVec
+	joinFailures      *metrics.CounterVec
+	handshakeFailures *metrics.CounterVec
+	rateLimited       *metrics.CounterVec
+}
+
+func newServerMetrics(s *Server) *serverMetrics {
+	r := metrics.NewRegistry()
+	m := &serverMetrics{registry: r}
+
+	r.GaugeFunc("void_rooms", "Rooms currently open.", func() float64 {
+		return float64(s.RoomCount())
+	})
+	r.GaugeFunc("void_connections", "Client connections currently open.", func() float64 {
+		s.connsMu.Lock()
+		defer s.connsMu.Unlock()
+		return float64(len(s.conns))
+	})
+	r.GaugeFunc("void_suspended_sessions", "Dropped sessions waiting to be resumed.", func() float64 {
+		s.suspendedMu.Lock()
+		defer s.suspendedMu.Unlock()
+		return float64(len(s.suspended))
+	})
+	r.HistogramFunc("void_room_size", "Members of the rooms currently open.", roomSizeBuckets, func(observe func(float64)) {
+		s.roomsMu.RLock()
+		rooms := make([]*Room, 0, len(s.rooms))
+		for _, room := range s.rooms {
+			rooms = append(rooms, room)
+		}
+		s.roomsMu.RUnlock()
+		for _, room := range rooms {
+			observe(float64(room.ClientCount()))
+		}
+	})
+
+	m.framesIn = r.CounterVec("void_frames_received_total", "Frames received from clients by message type.", "type")
+	m.framesOut = r.CounterVec("void_frames_sent_total", "Frames written to clients by message type.", "type")
+	m.bytes = r.CounterVec("void_bytes_total", "Frame bytes received from and sent to clients.", "direction", "received", "sent")
+	r.CounterFunc("void_dropped_frames_total", "Frames dropped because a client's send queue was full.", func() float64 {
+		return float64(s.dropped.Load())
+	})
+	r.CounterFunc("void_slow_consumer_disconnects_total", "Clients disconnected for dropping too many frames.", func() float64 {
+		return float64(s.evictions.Load())
+	})
+	m.handshakeFailures = r.CounterVec("void_handshake_failures_total", "Rejected protocol handshakes by reason.", "reason",
+		helloLabel(chatpb.HelloError_HELLO_REQUIRED), helloLabel(chatpb.HelloError_UNSUPPORTED_VERSION), helloLabel(chatpb.HelloError_UNSUPPORTED_FEATURE))
+	m.joinFailures = r.CounterVec("void_join_failures_total", "Rejected room joins by reason.", "reason",
+		errorLabel(chatpb.ErrorCode_DRAINING), errorLabel(chatpb.ErrorCode_TOO_MANY_ROOMS), errorLabel(chatpb.ErrorCode_INVALID_VERIFIER),
+		errorLabel(chatpb.ErrorCode_INVALID_PASSWORD), errorLabel(chatpb.ErrorCode_ROOM_FULL), errorLabel(chatpb.ErrorCode_RATE_LIMITED),
+		errorLabel(chatpb.ErrorCode_ROOM_NOT_FOUND), errorLabel(chatpb.ErrorCode_ROOM_EXISTS))
+	m.rateLimited = r.CounterVec("void_rate_limited_total", "Requests refused by a rate limit, by limit scope.", "scope",
+		scopeLabel(chatpb.RateLimitScope_CONNECTION), scopeLabel(chatpb.RateLimitScope_IP), scopeLabel(chatpb.RateLimitScope_ROOM))
+	r.CounterFunc("void_rejected_connections_total", "Connections refused by the per-IP connection limit.", func() float64 {
+		return float64(s.rejected.Load())
+	})
+	return m
+}
+
+func (m *serverMetrics) received(msg *chatpb.ClientMessage, size int, err error) {
+	m.bytes.With("received").Add(uint64(size))
+	if err != nil {
+		m.framesIn.With("invalid").Inc()
+		return
+	}
+	m.framesIn.With(payloadName(msg.ProtoReflect().WhichOneof(clientPayloads))).Inc()
+}
+
+func (m *serverMetrics) sent(frame []byte) {
+	m.bytes.With("sent").Add(uint64(len(frame)))
+	number, _, n := protowire.ConsumeTag(frame)
+	if n < 0 {
+		m.framesOut.With("unknown").Inc()
+		return
+	}
+	m.framesOut.With(payloadName(serverPayloads.Fields().ByNumber(number))).Inc()
+}
+
+func payloadName(field protoreflect.FieldDescriptor) string {
+	if field == nil {
+		return "unknown"
+	}
+	return string(field.Name())
+}
+
+func (s *Server) MetricsHandler() http.Handler {
+	return s.metrics.registry.Handler()
+}
+
+func (s *Server) StartMetrics() error {
+	listener, err := net.Listen("tcp", s.options().MetricsAddress)
+	if err != nil {
+		return err
+	}
+	return s.ServeMetrics(context.Background(), listener)
+}
+
+func (s *Server) ServeMetrics(ctx context.Context, listener net.Listener) error {
+	mux := http.NewServeMux()
+	mux.Handle(MetricsPath, s.MetricsHandler())
+	mux.Handle(HealthPath, s.HealthHandler())
+	mux.Handle(ReadyPath, s.ReadyHandler())
+
+	httpServer := &http.Server{Handler: mux}
+	if !s.addHTTPServer(httpServer, &s.metricsAddr, listener.Addr()) {
+		listener.Close()
+		return ErrServerClosed
+	}
+	stop := context.AfterFunc(ctx, func() {
+		httpServer.Close()
+	})
+	defer stop()
+
+	s.logger().Info("Metrics listening", "addr", listener.Addr().String(), "path", MetricsPath)
+	err := httpServer.Serve(listener)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return ErrServerClosed
+	}
+	return err
+}
+
+func (s *Server) MetricsAddr() net.Addr {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+	return s.metricsAddr
+}
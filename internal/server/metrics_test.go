@@ -0,0 +1,58 @@
+package server
+
+import (
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Void/internal/protocol"
+	"Void/proto/chatpb"
+)
+
+func TestMetricsHaveNoIdentifyingLabels(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := New(opts)
+	create := func(roomID string) []byte {
+		return marshal(t, &chatpb.ClientMessage{
+			Payload: &chatpb.ClientMessage_CreateRoom{CreateRoom: &chatpb.RoomRequest{RoomId: roomID, Username: "alice"}},
+		})
+	}
+	c := newConnection(&scriptedConn{frames: [][]byte{create("secret-room"), create("other-room")}}, s)
+	c.version = protocol.Version
+	if err := c.readPump(); err != io.EOF {
+		t.Fatalf("readPump: %v", err)
+	}
+	if c.Room == nil {
+		t.Fatal("room not joined")
+	}
+
+	rec := httptest.NewRecorder()
+	s.MetricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", MetricsPath, nil))
+	body := rec.Body.String()
+
+	for _, secret := range []string{"secret-room", "other-room", c.ID, "alice", "127.0.0.1"} {
+		if strings.Contains(body, secret) {
+			t.Errorf("metrics mention %q", secret)
+		}
+	}
+	allowed := map[string]bool{"type": true, "direction": true, "reason": true, "scope": true, "le": true}
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		open := strings.IndexByte(line, '{')
+		if open < 0 {
+			continue
+		}
+		label := line[open+1 : open+strings.IndexByte(line[open:], '=')]
+		if !allowed[label] {
+			t.Errorf("unexpected label %q in %q", label, line)
+		}
+	}
+	if !strings.Contains(body, "void_rooms 1\n") || !strings.Contains(body, `void_frames_received_total{type="create_room"} 2`) {
+		t.Errorf("scrape does not reflect the join:\n%s", body)
+	}
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"os"
+	"time"
+
+	"Void/internal/framing"
+	"Void/internal/logging"
+)
+
+const (
+	DefaultAddress          = ":8080"
+	DefaultHandshakeTimeout = 10 * time.Second
+	DefaultIdleTimeout      = 60 * time.Second
+	DefaultWriteTimeout     = 10 * time.Second
+	DefaultResumeWindow     = 30 * time.Second
+	DefaultShutdownGrace    = time.Second
+	DefaultSendQueueSize    = 256
+	DefaultMaxConnsPerIP    = 32
+	DefaultMaxDrops         = 64
+)
+
+type SlowConsumerPolicy string
+
+const (
+	SlowConsumerDropNewest SlowConsumerPolicy = "drop-newest"
+	SlowConsumerDropOldest SlowConsumerPolicy = "drop-oldest"
+	SlowConsumerDisconnect SlowConsumerPolicy = "disconnect"
+)
+
+func (p SlowConsumerPolicy) Valid() bool {
+	switch p {
+	case SlowConsumerDropNewest, SlowConsumerDropOldest, SlowConsumerDisconnect:
+		return true
+	}
+	return false
+}
+
+var (
+	DefaultConnectionRate = RateLimit{PerSecond: 5, Burst: 20}
+	DefaultIPRate         = RateLimit{PerSecond: 20, Burst: 60}
+	DefaultRoomRate       = RateLimit{PerSecond: 30, Burst: 100}
+)
+
+type Options struct {
+	Address          string
+	WebSocketAddress string
+	MetricsAddress   string
+	AdminSocket      string
+	TrustedProxies   []string
+	TLSConfig        *tls.Config
+	MaxFrameSize     int
+	SendQueueSize    int
+	SlowConsumer     SlowConsumerPolicy
+	MaxDrops         int
+	MaxConnsPerIP    int
+	MaxRooms         int
+	MaxRoomSize      int
+	ConnectionRate   RateLimit
+	IPRate           RateLimit
+	RoomRate         RateLimit
+	HandshakeTimeout time.Duration
+	IdleTimeout      time.Duration
+	WriteTimeout     time.Duration
+	ResumeWindow     time.Duration
+	ReconnectAfter   time.Duration
+	AlternateAddress string
+	ShutdownGrace    time.Duration
+	LogLevel         slog.Level
+	Logger           *slog.Logger
+	Redaction        logging.Redaction
+	Version          string
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Address:          DefaultAddress,
+		MaxFrameSize:     framing.DefaultMaxFrameSize,
+		SendQueueSize:    DefaultSendQueueSize,
+		SlowConsumer:     SlowConsumerDropNewest,
+		MaxDrops:         DefaultMaxDrops,
+		MaxConnsPerIP:    DefaultMaxConnsPerIP,
+		ConnectionRate:   DefaultConnectionRate,
+		IPRate:           DefaultIPRate,
+		RoomRate:         DefaultRoomRate,
+		HandshakeTimeout: DefaultHandshakeTimeout,
+		IdleTimeout:      DefaultIdleTimeout,
+		WriteTimeout:     DefaultWriteTimeout,
+		ResumeWindow:     DefaultResumeWindow,
+		ShutdownGrace:    DefaultShutdownGrace,
+		Redaction:        logging.DefaultRedaction(),
+	}
+}
+
+func (o Options) withDefaults() Options {
+	defaults := DefaultOptions()
+	if o.Address == "" {
+		o.Address = defaults.Address
+	}
+	if o.MaxFrameSize <= 0 {
+		o.MaxFrameSize = defaults.MaxFrameSize
+	}
+	if o.SendQueueSize <= 0 {
+		o.SendQueueSize = defaults.SendQueueSize
+	}
+	if !o.SlowConsumer.Valid() {
+		o.SlowConsumer = defaults.SlowConsumer
+	}
+	if o.MaxDrops <= 0 {
+		o.MaxDrops = defaults.MaxDrops
+	}
+	if o.HandshakeTimeout <= 0 {
+		o.HandshakeTimeout = defaults.HandshakeTimeout
+	}
+	if o.IdleTimeout <= 0 {
+		o.IdleTimeout = defaults.IdleTimeout
+	}
+	if o.WriteTimeout <= 0 {
+		o.WriteTimeout = defaults.WriteTimeout
+	}
+	if o.ResumeWindow == 0 {
+		o.ResumeWindow = defaults.ResumeWindow
+	}
+	if o.ShutdownGrace <= 0 {
+		o.ShutdownGrace = defaults.ShutdownGrace
+	}
+	if !o.Redaction.IP.Valid() {
+		o.Redaction.IP = defaults.Redaction.IP
+	}
+	if !o.Redaction.Room.Valid() {
+		o.Redaction.Room = defaults.Redaction.Room
+	}
+	if o.Logger == nil {
+		o.Logger = logging.New(os.Stderr, logging.FormatText, o.LogLevel)
+	}
+	return o
+}
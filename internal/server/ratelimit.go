@@ -0,0 +1,109 @@
+package server
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"Void/proto/chatpb"
+)
+
+const bucketSweepInterval = time.Minute
+
+type RateLimit struct {
+	PerSecond float64
+	Burst     int
+}
+
+func (l RateLimit) enabled() bool {
+	return l.PerSecond > 0 && l.Burst > 0
+}
+
+type RateLimitStats struct {
+	Connection          uint64
+	IP                  uint64
+	Room                uint64
+	RejectedConnections uint64
+}
+
+func (s *Server) RateLimitStats() RateLimitStats {
+	return RateLimitStats{
+		Connection:          s.metrics.rateLimited.With(scopeLabel(chatpb.RateLimitScope_CONNECTION)).Value(),
+		IP:                  s.metrics.rateLimited.With(scopeLabel(chatpb.RateLimitScope_IP)).Value(),
+		Room:                s.metrics.rateLimited.With(scopeLabel(chatpb.RateLimitScope_ROOM)).Value(),
+		RejectedConnections: s.rejected.Load(),
+	}
+}
+
+func scopeLabel(scope chatpb.RateLimitScope) string {
+	return strings.ToLower(scope.String())
+}
+
+type tokenBucket struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) take(limit RateLimit, now time.Time) time.Duration {
+	if !limit.enabled() {
+		return 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(limit, now)
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration(math.Ceil((1 - b.tokens) / limit.PerSecond * float64(time.Second)))
+}
+
+func (b *tokenBucket) full(limit RateLimit, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(limit, now)
+	return b.tokens >= float64(limit.Burst)
+}
+
+func (b *tokenBucket) refill(limit RateLimit, now time.Time) {
+	burst := float64(limit.Burst)
+	if b.last.IsZero() {
+		b.tokens = burst
+	} else {
+		b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*limit.PerSecond)
+	}
+	b.last = now
+}
+
+type bucketMap struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	swept   time.Time
+}
+
+func (m *bucketMap) get(key string, limit RateLimit, now time.Time) *tokenBucket {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.buckets == nil {
+		m.buckets = make(map[string]*tokenBucket)
+	}
+
+	if now.Sub(m.swept) >= bucketSweepInterval {
+		for k, b := range m.buckets {
+			if b.full(limit, now) {
+				delete(m.buckets, k)
+			}
+		}
+		m.swept = now
+	}
+
+	b, exists := m.buckets[key]
+	if !exists {
+		b = &tokenBucket{}
+		m.buckets[key] = b
+	}
+	return b
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucketBurstAndRefill(t *testing.T) {
+	limit := RateLimit{PerSecond: 2, Burst: 3}
+	now := time.Unix(1000, 0)
+	var b tokenBucket
+
+	for i := 0; i < limit.Burst; i++ {
+		if wait := b.take(limit, now); wait != 0 {
+			t.Fatalf("take %d: wait = %v, want 0", i, wait)
+		}
+	}
+	if wait := b.take(limit, now); wait != 500*time.Millisecond {
+		t.Fatalf("empty bucket: wait = %v, want 500ms", wait)
+	}
+
+	now = now.Add(250 * time.Millisecond)
+	if wait := b.take(limit, now); wait != 250*time.Millisecond {
+		t.Fatalf("half refilled: wait = %v, want 250ms", wait)
+	}
+
+	now = now.Add(250 * time.Millisecond)
+	if wait := b.take(limit, now); wait != 0 {
+		t.Fatalf("refilled: wait = %v, want 0", wait)
+	}
+
+	now = now.Add(time.Hour)
+	for i := 0; i < limit.Burst; i++ {
+		if wait := b.take(limit, now); wait != 0 {
+			t.Fatalf("after idle take %d: wait = %v, want 0", i, wait)
+		}
+	}
+	if wait := b.take(limit, now); wait == 0 {
+		t.Fatal("idle time refilled past the burst")
+	}
+}
+
+func TestTokenBucketDisabled(t *testing.T) {
+	now := time.Unix(1000, 0)
+	for _, limit := range []RateLimit{{}, {PerSecond: 1}, {Burst: 1}} {
+		var b tokenBucket
+		for i := 0; i < 100; i++ {
+			if wait := b.take(limit, now); wait != 0 {
+				t.Fatalf("%+v: wait = %v, want 0", limit, wait)
+			}
+		}
+	}
+}
+
+func TestBucketMapSharesAndSweeps(t *testing.T) {
+	limit := RateLimit{PerSecond: 1, Burst: 1}
+	now := time.Unix(1000, 0)
+	var m bucketMap
+
+	m.get("a", limit, now).take(limit, now)
+	if wait := m.get("a", limit, now).take(limit, now); wait == 0 {
+		t.Fatal("same key did not share a bucket")
+	}
+	if wait := m.get("b", limit, now).take(limit, now); wait != 0 {
+		t.Fatalf("other key: wait = %v, want 0", wait)
+	}
+
+	now = now.Add(bucketSweepInterval)
+	m.get("c", limit, now)
+	if len(m.buckets) != 1 {
+		t.Fatalf("%d buckets after sweep, want 1", len(m.buckets))
+	}
+
+	m.get("c", limit, now).take(limit, now)
+	m.get("d", limit, now.Add(bucketSweepInterval-time.Millisecond))
+	if _, ok := m.buckets["c"]; !ok {
+		t.Fatal("swept before the interval elapsed")
+	}
+}
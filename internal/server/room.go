@@ -6,32 +6,61 @@ import (
 
 type Room struct {
 	ID       string
-	Password string
+	Verifier []byte
 	Clients  map[string]*Connection
+	departed map[string]struct{}
 	mu       sync.RWMutex
+	limiter  tokenBucket
 }
 
-func NewRoom(id string, password string) *Room {
+func NewRoom(id string, verifier []byte) *Room {
 	return &Room{
 		ID:       id,
-		Password: password,
+		Verifier: verifier,
 		Clients:  make(map[string]*Connection),
+		departed: make(map[string]struct{}),
 	}
 }
 
+func (r *Room) HasPassword() bool {
+	return len(r.Verifier) > 0
+}
+
 func (r *Room) AddClient(conn *Connection) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.Clients[conn.ID] = conn
+	delete(r.departed, conn.ID)
+}
+
+func (r *Room) TryAddClient(conn *Connection, max int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if max > 0 && len(r.Clients) >= max {
+		return false
+	}
+	r.Clients[conn.ID] = conn
+	delete(r.departed, conn.ID)
+	return true
 }
 
 func (r *Room) RemoveClient(connID string) bool {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	delete(r.Clients, connID)
+	if _, exists := r.Clients[connID]; exists {
+		delete(r.Clients, connID)
+		r.departed[connID] = struct{}{}
+	}
 	return len(r.Clients) == 0
 }
 
+func (r *Room) HasDeparted(connID string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, departed := r.departed[connID]
+	return departed
+}
+
 func (r *Room) GetClients() map[string]*Connection {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
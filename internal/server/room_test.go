@@ -0,0 +1,84 @@
+package server
+
+import (
+	"testing"
+
+	"Void/proto/chatpb"
+)
+
+func testConnection(s *Server, id string, queue int) *Connection {
+	return &Connection{
+		ID:     id,
+		send:   make(chan []byte, queue),
+		done:   make(chan struct{}),
+		server: s,
+	}
+}
+
+func TestRouteEnvelopesStatuses(t *testing.T) {
+	opts := DefaultOptions()
+	opts.SlowConsumer = SlowConsumerDropNewest
+	s := New(opts)
+
+	room := NewRoom("room", nil)
+	sender := testConnection(s, "sender", 1)
+	ready := testConnection(s, "ready", 1)
+	full := testConnection(s, "full", 1)
+	suspended := testConnection(s, "suspended", 1)
+	gone := testConnection(s, "gone", 1)
+	for _, c := range []*Connection{sender, ready, full, suspended, gone} {
+		room.AddClient(c)
+		c.Room = room
+	}
+	full.send <- []byte("queued")
+	close(suspended.done)
+	room.RemoveClient(gone.ID)
+
+	envelopes := []*chatpb.Envelope{
+		{RecipientUserId: "ready"},
+		{RecipientUserId: "full"},
+		{RecipientUserId: "suspended"},
+		{RecipientUserId: "gone"},
+		{RecipientUserId: "stranger"},
+	}
+	build := func([]byte, chatpb.CipherSuite) *chatpb.ServerMessage { return &chatpb.ServerMessage{} }
+	want := map[string]chatpb.DeliveryStatus{
+		"ready":     chatpb.DeliveryStatus_DELIVERED,
+		"full":      chatpb.DeliveryStatus_DROPPED,
+		"suspended": chatpb.DeliveryStatus_QUEUED,
+		"gone":      chatpb.DeliveryStatus_DEPARTED,
+		"stranger":  chatpb.DeliveryStatus_UNKNOWN_RECIPIENT,
+	}
+
+	results := sender.routeEnvelopes(envelopes, build)
+	if len(results) != len(want) {
+		t.Fatalf("%d results, want %d", len(results), len(want))
+	}
+	for _, result := range results {
+		if result.Status != want[result.RecipientUserId] {
+			t.Errorf("%s: status %s, want %s", result.RecipientUserId, result.Status, want[result.RecipientUserId])
+		}
+	}
+	if len(suspended.send) != 1 {
+		t.Error("message for a suspended peer was not kept for resume")
+	}
+}
+
+func TestRoomDepartedClearedOnRejoin(t *testing.T) {
+	s := New(DefaultOptions())
+	room := NewRoom("room", nil)
+	c := testConnection(s, "member", 1)
+
+	if room.HasDeparted(c.ID) {
+		t.Fatal("never joined but departed")
+	}
+	room.AddClient(c)
+	room.RemoveClient(c.ID)
+	if !room.HasDeparted(c.ID) {
+		t.Fatal("left but not departed")
+	}
+	room.AddClient(c)
+	if room.HasDeparted(c.ID) {
+		t.Fatal("resumed but still departed")
+	}
+}
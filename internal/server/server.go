@@ -1,74 +1,281 @@
 package server
 
 import (
-	"log"
+	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
 	"net"
+	"net/http"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"Void/internal/logging"
+	"Void/internal/transport"
 	"Void/proto/chatpb"
 
 	"google.golang.org/protobuf/proto"
 )
 
 type Server struct {
-	rooms   map[string]*Room
-	roomsMu sync.RWMutex
-	port    string
+	rooms       map[string]*Room
+	roomsMu     sync.RWMutex
+	opts        atomic.Pointer[Options]
+	ipConns     map[string]int
+	suspended   map[string]*Connection
+	suspendedMu sync.Mutex
+	listeners   []net.Listener
+	httpServers []*http.Server
+	wsAddr      net.Addr
+	conns       map[*Connection]struct{}
+	connsMu     sync.Mutex
+	closing     bool
+	readers     sync.WaitGroup
+	writers     sync.WaitGroup
+	draining    atomic.Bool
+	ipLimiters  bucketMap
+	metrics     *serverMetrics
+	metricsAddr net.Addr
+	rejected    atomic.Uint64
+	dropped     atomic.Uint64
+	evictions   atomic.Uint64
+	started     time.Time
+	redactor    *logging.Redactor
 }
 
-func NewServer(port string) *Server {
-	return &Server{
-		rooms: make(map[string]*Room),
-		port:  port,
+func New(opts Options) *Server {
+	s := &Server{
+		rooms:     make(map[string]*Room),
+		suspended: make(map[string]*Connection),
+		conns:     make(map[*Connection]struct{}),
+		ipConns:   make(map[string]int),
+		started:   time.Now(),
 	}
+	s.metrics = newServerMetrics(s)
+	opts = opts.withDefaults()
+	s.opts.Store(&opts)
+	s.redactor = logging.NewRedactor(opts.Redaction)
+	return s
+}
+
+func NewServer(port string) *Server {
+	opts := DefaultOptions()
+	opts.Address = ":" + port
+	return New(opts)
+}
+
+func (s *Server) options() *Options {
+	return s.opts.Load()
+}
+
+func (s *Server) logger() *slog.Logger {
+	return s.options().Logger
+}
+
+func (s *Server) Reload(opts Options) {
+	current := s.options()
+	opts = opts.withDefaults()
+	opts.Address = current.Address
+	opts.WebSocketAddress = current.WebSocketAddress
+	opts.MetricsAddress = current.MetricsAddress
+	opts.AdminSocket = current.AdminSocket
+	opts.Version = current.Version
+	opts.TLSConfig = current.TLSConfig
+	opts.MaxFrameSize = current.MaxFrameSize
+	s.opts.Store(&opts)
+	s.redactor.SetRedaction(opts.Redaction)
 }
 
 func (s *Server) Start() error {
-	listener, err := net.Listen("tcp", ":"+s.port)
+	listener, err := net.Listen("tcp", s.options().Address)
 	if err != nil {
 		return err
 	}
-	defer listener.Close()
+	return s.Serve(context.Background(), listener)
+}
+
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	if s.options().TLSConfig != nil {
+		listener = tls.NewListener(listener, s.options().TLSConfig)
+	}
+	if !s.addListener(listener) {
+		listener.Close()
+		return ErrServerClosed
+	}
+	defer s.removeListener(listener)
+	stop := context.AfterFunc(ctx, func() {
+		listener.Close()
+	})
+	defer stop()
 
-	log.Printf("Server started on port %s", s.port)
+	s.logger().Info("Server listening", "addr", listener.Addr().String(), "tls", s.options().TLSConfig != nil)
 
 	for {
 		conn, err := listener.Accept()
 		if err != nil {
-			log.Printf("Error accepting connection: %v", err)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if s.isClosing() {
+				return ErrServerClosed
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return err
+			}
+			s.logger().Error("Error accepting connection", "error", err)
 			continue
 		}
-		go s.serveClient(conn)
+		go s.serveClient(transport.NewStream(conn, s.options().MaxFrameSize))
 	}
 }
 
-func (s *Server) serveClient(conn net.Conn) {
-	client := newConnection(conn, s)
+func (s *Server) StartWebSocket() error {
+	listener, err := net.Listen("tcp", s.options().WebSocketAddress)
+	if err != nil {
+		return err
+	}
+	return s.ServeWebSocket(context.Background(), listener)
+}
+
+func (s *Server) ServeWebSocket(ctx context.Context, listener net.Listener) error {
+	handler := transport.NewWebSocketHandler(s.options().MaxFrameSize, s.serveClient)
+	handler.SetClientAddr(s.forwardedAddr)
+	mux := http.NewServeMux()
+	mux.Handle(transport.WebSocketPath, handler)
+
+	httpServer := &http.Server{
+		Handler:   mux,
+		TLSConfig: s.options().TLSConfig,
+	}
+	if !s.addHTTPServer(httpServer, &s.wsAddr, listener.Addr()) {
+		listener.Close()
+		return ErrServerClosed
+	}
+	stop := context.AfterFunc(ctx, func() {
+		httpServer.Close()
+	})
+	defer stop()
+
+	s.logger().Info("WebSocket endpoint listening", "addr", listener.Addr().String(), "path", transport.WebSocketPath, "tls", s.options().TLSConfig != nil)
+	var err error
+	if s.options().TLSConfig != nil {
+		err = httpServer.ServeTLS(listener, "", "")
+	} else {
+		err = httpServer.Serve(listener)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return ErrServerClosed
+	}
+	return err
+}
+
+func (s *Server) forwardedAddr(r *http.Request) net.Addr {
+	proxies, err := transport.ParseNetworks(s.options().TrustedProxies)
+	if err != nil || len(proxies) == 0 {
+		return nil
+	}
+	return transport.ForwardedAddr(r, proxies)
+}
 
-	defer func() {
-		close(client.send)
+func (s *Server) Addr() net.Addr {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+	if len(s.listeners) == 0 {
+		return nil
+	}
+	return s.listeners[0].Addr()
+}
+
+func (s *Server) WebSocketAddr() net.Addr {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+	return s.wsAddr
+}
+
+func (s *Server) serveClient(conn transport.Conn) {
+	client := newConnection(conn, s)
+	if !s.track(client) {
 		conn.Close()
-		if client.Room != nil {
-			s.removeClient(client)
-		}
-	}()
+		return
+	}
 
 	go client.writePump()
-	client.readPump()
+	client.sendHello()
+	err := client.readPump()
+	s.readers.Done()
+
+	if !s.untrack(client) {
+		return
+	}
+	close(client.done)
+	conn.Close()
+
+	if client.Room == nil {
+		return
+	}
+	if s.options().ResumeWindow > 0 && len(client.resumeToken) > 0 {
+		s.suspend(client)
+	} else if isTimeout(err) {
+		s.removeClient(client, chatpb.LeaveReason_TIMEOUT)
+	} else {
+		s.removeClient(client, chatpb.LeaveReason_LEFT)
+	}
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
 }
 
-func (s *Server) removeClient(c *Connection) {
+func (s *Server) suspend(c *Connection) {
+	token := string(c.resumeToken)
+
+	s.suspendedMu.Lock()
+	s.suspended[token] = c
+	s.suspendedMu.Unlock()
+	s.logger().Debug("Session suspended", s.redactor.Room(c.Room.ID), s.redactor.IP(c.ip), "resume_window", s.options().ResumeWindow)
+
+	time.AfterFunc(s.options().ResumeWindow, func() {
+		if s.takeSuspended(c.resumeToken, c.Room) == c {
+			s.removeClient(c, chatpb.LeaveReason_TIMEOUT)
+		}
+	})
+}
+
+func (s *Server) takeSuspended(token []byte, room *Room) *Connection {
+	if len(token) == 0 {
+		return nil
+	}
+
+	s.suspendedMu.Lock()
+	defer s.suspendedMu.Unlock()
+	c, exists := s.suspended[string(token)]
+	if !exists || c.Room != room {
+		return nil
+	}
+	delete(s.suspended, string(token))
+	return c
+}
+
+func (s *Server) removeClient(c *Connection, reason chatpb.LeaveReason) {
 	if c.Room == nil {
 		return
 	}
 
 	empty := c.Room.RemoveClient(c.ID)
+	s.logger().Info("Room left", s.redactor.Room(c.Room.ID), s.redactor.IP(c.ip), "reason", strings.ToLower(reason.String()), "members", c.Room.ClientCount())
 
 	if !empty {
 		peerLeft := &chatpb.ServerMessage{
 			Payload: &chatpb.ServerMessage_PeerLeft{
 				PeerLeft: &chatpb.PeerLeft{
 					UserId: c.ID,
+					Reason: reason,
 				},
 			},
 		}
@@ -77,15 +284,29 @@ func (s *Server) removeClient(c *Connection) {
 	}
 
 	if empty {
-		s.roomsMu.Lock()
-		delete(s.rooms, c.Room.ID)
-		s.roomsMu.Unlock()
+		s.dropIfEmpty(c.Room)
 	}
 
 	c.Room = nil
 }
 
-func (s *Server) GetPort() string {
-	return s.port
+func (s *Server) dropIfEmpty(room *Room) {
+	s.roomsMu.Lock()
+	defer s.roomsMu.Unlock()
+	if s.rooms[room.ID] == room && room.ClientCount() == 0 {
+		delete(s.rooms, room.ID)
+	}
+}
+
+type ServerError string
+
+func (e ServerError) Error() string {
+	return string(e)
 }
 
+const (
+	ErrServerClosed     = ServerError("server closed")
+	ErrAdminSocketInUse = ServerError("admin socket is in use by another server")
+	ErrHandshakeFailed  = ServerError("handshake failed")
+)
+
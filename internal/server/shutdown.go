@@ -0,0 +1,199 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"Void/proto/chatpb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func (s *Server) Drain() {
+	s.draining.Store(true)
+}
+
+func (s *Server) Undrain() {
+	s.draining.Store(false)
+}
+
+func (s *Server) Draining() bool {
+	return s.draining.Load()
+}
+
+func (s *Server) RoomCount() int {
+	s.roomsMu.RLock()
+	defer s.roomsMu.RUnlock()
+	return len(s.rooms)
+}
+
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.connsMu.Lock()
+	if s.closing {
+		s.connsMu.Unlock()
+		return ErrServerClosed
+	}
+	s.closing = true
+	listeners := s.listeners
+	httpServers := s.httpServers
+	conns := make([]*Connection, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.connsMu.Unlock()
+
+	for _, listener := range listeners {
+		listener.Close()
+	}
+	for _, httpServer := range httpServers {
+		httpServer.Shutdown(ctx)
+	}
+
+	data, _ := proto.Marshal(s.shutdownNotice())
+	for _, c := range conns {
+		c.sendData(data)
+	}
+
+	select {
+	case <-time.After(s.options().ShutdownGrace):
+	case <-ctx.Done():
+	}
+
+	for _, c := range conns {
+		c.stopReading()
+	}
+	if err := wait(ctx, &s.readers); err != nil {
+		closeAll(conns)
+		return err
+	}
+
+	for _, c := range conns {
+		close(c.drain)
+	}
+	if err := wait(ctx, &s.writers); err != nil {
+		closeAll(conns)
+		return err
+	}
+	return nil
+}
+
+func (s *Server) shutdownNotice() *chatpb.ServerMessage {
+	return &chatpb.ServerMessage{
+		Payload: &chatpb.ServerMessage_ServerShutdown{
+			ServerShutdown: &chatpb.ServerShutdown{
+				Reason:                "Server shutting down",
+				ReconnectAfterSeconds: uint32(s.options().ReconnectAfter / time.Second),
+				AlternateAddress:      s.options().AlternateAddress,
+			},
+		},
+	}
+}
+
+func (s *Server) addListener(listener net.Listener) bool {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+	if s.closing {
+		return false
+	}
+	s.listeners = append(s.listeners, listener)
+	return true
+}
+
+func (s *Server) removeListener(listener net.Listener) {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+	listeners := make([]net.Listener, 0, len(s.listeners))
+	for _, l := range s.listeners {
+		if l != listener {
+			listeners = append(listeners, l)
+		}
+	}
+	s.listeners = listeners
+}
+
+func (s *Server) addHTTPServer(httpServer *http.Server, target *net.Addr, addr net.Addr) bool {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+	if s.closing {
+		return false
+	}
+	s.httpServers = append(s.httpServers, httpServer)
+	if target != nil {
+		*target = addr
+	}
+	return true
+}
+
+func (s *Server) isClosing() bool {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+	return s.closing
+}
+
+func (s *Server) track(c *Connection) bool {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+	if s.closing {
+		return false
+	}
+	if limit := s.options().MaxConnsPerIP; limit > 0 && s.ipConns[c.ip] >= limit {
+		s.rejected.Add(1)
+		s.logger().Warn("Connection rejected", s.redactor.IP(c.ip), "reason", "per-IP limit", "limit", limit)
+		return false
+	}
+	s.ipConns[c.ip]++
+	s.conns[c] = struct{}{}
+	s.readers.Add(1)
+	s.writers.Add(1)
+	return true
+}
+
+func (s *Server) untrack(c *Connection) bool {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+	if s.closing {
+		return false
+	}
+	delete(s.conns, c)
+	if s.ipConns[c.ip] <= 1 {
+		delete(s.ipConns, c.ip)
+	} else {
+		s.ipConns[c.ip]--
+	}
+	return true
+}
+
+func remoteIP(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
+
+func wait(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func closeAll(conns []*Connection) {
+	for _, c := range conns {
+		c.Conn.Close()
+	}
+}
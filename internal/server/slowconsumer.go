@@ -0,0 +1,87 @@
+package server
+
+import (
+	"Void/internal/protocol"
+	"Void/proto/chatpb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+type DropStats struct {
+	Messages    uint64
+	Disconnects uint64
+}
+
+func (s *Server) DropStats() DropStats {
+	return DropStats{
+		Messages:    s.dropped.Load(),
+		Disconnects: s.evictions.Load(),
+	}
+}
+
+func (c *Connection) sendData(data []byte) bool {
+	select {
+	case c.send <- data:
+		return true
+	default:
+	}
+
+	opts := c.server.options()
+	if opts.SlowConsumer == SlowConsumerDropOldest {
+		for {
+			select {
+			case <-c.send:
+				c.recordDrop()
+			default:
+			}
+			select {
+			case c.send <- data:
+				return true
+			default:
+			}
+		}
+	}
+
+	c.recordDrop()
+	if opts.SlowConsumer == SlowConsumerDisconnect && c.dropped.Load() >= uint64(opts.MaxDrops) {
+		c.evict()
+	}
+	return false
+}
+
+func (c *Connection) recordDrop() {
+	c.dropped.Add(1)
+	c.pendingDrops.Add(1)
+	c.server.dropped.Add(1)
+}
+
+func (c *Connection) evict() {
+	select {
+	case <-c.done:
+		return
+	default:
+	}
+	if !c.evicted.CompareAndSwap(false, true) {
+		return
+	}
+	c.server.evictions.Add(1)
+	c.server.logger().Warn("Slow client disconnected", c.server.redactor.IP(c.ip), "dropped", c.dropped.Load())
+	c.Conn.Close()
+}
+
+func (c *Connection) notifyDrops() error {
+	count := c.pendingDrops.Swap(0)
+	if count == 0 || !c.supports(protocol.FeatureDropNotice) {
+		return nil
+	}
+
+	msg := &chatpb.ServerMessage{
+		Payload: &chatpb.ServerMessage_MessagesDropped{
+			MessagesDropped: &chatpb.MessagesDropped{
+				Count: count,
+			},
+		},
+	}
+	data, _ := proto.Marshal(msg)
+	return c.write(data)
+}
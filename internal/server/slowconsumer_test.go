@@ -0,0 +1,110 @@
+package server
+
+import (
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"Void/internal/protocol"
+	"Void/proto/chatpb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func fillQueue(t *testing.T, policy SlowConsumerPolicy, maxDrops int, features []string) (*Connection, *scriptedConn, []bool) {
+	t.Helper()
+	opts := DefaultOptions()
+	opts.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	opts.SendQueueSize = 2
+	opts.SlowConsumer = policy
+	opts.MaxDrops = maxDrops
+	conn := &scriptedConn{}
+	c := newConnection(conn, New(opts))
+	c.features = features
+
+	var queued []bool
+	for _, frame := range []string{"1", "2", "3", "4", "5"} {
+		queued = append(queued, c.sendData([]byte(frame)))
+	}
+	return c, conn, queued
+}
+
+func queuedFrames(c *Connection) []string {
+	var frames []string
+	for len(c.send) > 0 {
+		frames = append(frames, string(<-c.send))
+	}
+	return frames
+}
+
+func TestSlowConsumerPolicies(t *testing.T) {
+	tests := []struct {
+		policy SlowConsumerPolicy
+		queued []bool
+		frames []string
+		closed bool
+	}{
+		{SlowConsumerDropOldest, []bool{true, true, true, true, true}, []string{"4", "5"}, false},
+		{SlowConsumerDropNewest, []bool{true, true, false, false, false}, []string{"1", "2"}, false},
+		{SlowConsumerDisconnect, []bool{true, true, false, false, false}, []string{"1", "2"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			c, conn, queued := fillQueue(t, tt.policy, 2, nil)
+			if !reflect.DeepEqual(queued, tt.queued) {
+				t.Errorf("queued = %v, want %v", queued, tt.queued)
+			}
+			if frames := queuedFrames(c); !reflect.DeepEqual(frames, tt.frames) {
+				t.Errorf("frames = %v, want %v", frames, tt.frames)
+			}
+			if conn.closed != tt.closed {
+				t.Errorf("closed = %v, want %v", conn.closed, tt.closed)
+			}
+
+			stats := c.server.DropStats()
+			disconnects := uint64(0)
+			if tt.closed {
+				disconnects = 1
+			}
+			if stats.Messages != 3 || stats.Disconnects != disconnects {
+				t.Errorf("DropStats = %+v, want 3 messages and %d disconnects", stats, disconnects)
+			}
+		})
+	}
+}
+
+func TestDisconnectWaitsForMaxDrops(t *testing.T) {
+	_, conn, _ := fillQueue(t, SlowConsumerDisconnect, 4, nil)
+	if conn.closed {
+		t.Fatal("disconnected before reaching the drop limit")
+	}
+}
+
+func TestNotifyDrops(t *testing.T) {
+	c, conn, _ := fillQueue(t, SlowConsumerDropNewest, 0, []string{protocol.FeatureDropNotice})
+	if err := c.notifyDrops(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.notifyDrops(); err != nil {
+		t.Fatal(err)
+	}
+	if len(conn.written) != 1 {
+		t.Fatalf("%d notices written, want 1", len(conn.written))
+	}
+	msg := &chatpb.ServerMessage{}
+	if err := proto.Unmarshal(conn.written[0], msg); err != nil {
+		t.Fatal(err)
+	}
+	if dropped := msg.GetMessagesDropped(); dropped == nil || dropped.Count != 3 {
+		t.Fatalf("got %v, want a notice for 3 dropped messages", msg)
+	}
+
+	c, conn, _ = fillQueue(t, SlowConsumerDropNewest, 0, nil)
+	if err := c.notifyDrops(); err != nil {
+		t.Fatal(err)
+	}
+	if len(conn.written) != 0 || c.pendingDrops.Load() != 0 {
+		t.Fatal("notice sent to a client that did not negotiate drop-notice")
+	}
+}
@@ -0,0 +1,104 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func selfSigned(t *testing.T) (tls.Certificate, string) {
+	t.Helper()
+	dir := t.TempDir()
+	cert, err := LoadOrCreateSelfSigned(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), []string{"localhost"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, Fingerprint(cert.Certificate[0])
+}
+
+func handshake(t *testing.T, cert tls.Certificate, pin string) error {
+	t.Helper()
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", ServerConfig(cert))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		conn.(*tls.Conn).Handshake()
+		conn.Close()
+	}()
+
+	conn, err := tls.Dial("tcp", listener.Addr().String(), ClientConfig("localhost", pin))
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func colons(fingerprint string) string {
+	var pairs []string
+	for i := 0; i < len(fingerprint); i += 2 {
+		pairs = append(pairs, fingerprint[i:i+2])
+	}
+	return strings.Join(pairs, ":")
+}
+
+func TestNormalizeFingerprint(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"abcdef", "abcdef"},
+		{"ABCDEF", "abcdef"},
+		{"AB:cd:EF", "abcdef"},
+		{" ab cd ef \n", "abcdef"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeFingerprint(tt.in); got != tt.want {
+			t.Errorf("NormalizeFingerprint(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPinnedHandshake(t *testing.T) {
+	cert, fingerprint := selfSigned(t)
+
+	for _, pin := range []string{fingerprint, strings.ToUpper(fingerprint), colons(strings.ToUpper(fingerprint))} {
+		if err := handshake(t, cert, pin); err != nil {
+			t.Errorf("pin %q: %v", pin, err)
+		}
+	}
+
+	other, _ := selfSigned(t)
+	if err := handshake(t, other, fingerprint); !errors.Is(err, ErrPinMismatch) {
+		t.Fatalf("wrong certificate: err = %v, want %v", err, ErrPinMismatch)
+	}
+	if err := handshake(t, cert, fingerprint[:len(fingerprint)-2]); !errors.Is(err, ErrPinMismatch) {
+		t.Fatalf("truncated pin: err = %v, want %v", err, ErrPinMismatch)
+	}
+	if err := handshake(t, cert, ""); err == nil {
+		t.Fatal("self-signed certificate accepted without a pin")
+	}
+}
+
+func TestLoadOrCreateSelfSignedReuses(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "tls", "cert.pem"), filepath.Join(dir, "tls", "key.pem")
+
+	first, err := LoadOrCreateSelfSigned(certPath, keyPath, []string{"localhost"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := LoadOrCreateSelfSigned(certPath, keyPath, []string{"localhost"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Fingerprint(first.Certificate[0]) != Fingerprint(second.Certificate[0]) {
+		t.Fatal("certificate regenerated on reload")
+	}
+}
@@ -0,0 +1,114 @@
+package transport
+
+import (
+	"crypto/tls"
+	"net"
+	"net/url"
+	"strings"
+
+	"Void/internal/tlsutil"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	schemeTCP = "tcp"
+	schemeTLS = "tls"
+	schemeWS  = "ws"
+	schemeWSS = "wss"
+)
+
+func Dial(address string, maxFrameSize int) (Conn, error) {
+	if !strings.Contains(address, "://") {
+		conn, err := net.Dial("tcp", address)
+		if err != nil {
+			return nil, err
+		}
+		return NewStream(conn, maxFrameSize), nil
+	}
+
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+	pin := u.Query().Get("pin")
+
+	switch u.Scheme {
+	case schemeTCP:
+		conn, err := net.Dial("tcp", u.Host)
+		if err != nil {
+			return nil, err
+		}
+		return NewStream(conn, maxFrameSize), nil
+	case schemeTLS:
+		conn, err := tls.Dial("tcp", u.Host, tlsutil.ClientConfig(u.Hostname(), pin))
+		if err != nil {
+			return nil, err
+		}
+		return NewStream(conn, maxFrameSize), nil
+	case schemeWS, schemeWSS:
+		return dialWebSocket(u, pin, maxFrameSize)
+	default:
+		return nil, ErrUnsupportedScheme
+	}
+}
+
+func Redirect(address string, alternate string) (string, error) {
+	current := &url.URL{Scheme: schemeTCP, Host: address}
+	if strings.Contains(address, "://") {
+		var err error
+		current, err = url.Parse(address)
+		if err != nil {
+			return "", err
+		}
+	}
+	if !strings.Contains(alternate, "://") {
+		alternate = current.Scheme + "://" + alternate
+	}
+	target, err := url.Parse(alternate)
+	if err != nil {
+		return "", err
+	}
+	if target.Scheme != current.Scheme || target.Host == "" {
+		return "", ErrUnsafeRedirect
+	}
+
+	if !strings.Contains(address, "://") {
+		return target.Host, nil
+	}
+	redirected := *current
+	redirected.Host = target.Host
+	return redirected.String(), nil
+}
+
+func dialWebSocket(u *url.URL, pin string, maxFrameSize int) (Conn, error) {
+	dialer := *websocket.DefaultDialer
+	if u.Scheme == schemeWSS {
+		dialer.TLSClientConfig = tlsutil.ClientConfig(u.Hostname(), pin)
+	}
+
+	target := *u
+	query := target.Query()
+	query.Del("pin")
+	target.RawQuery = query.Encode()
+	if target.Path == "" {
+		target.Path = WebSocketPath
+	}
+
+	conn, _, err := dialer.Dial(target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return NewWebSocket(conn, maxFrameSize), nil
+}
+
+type TransportError string
+
+func (e TransportError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrUnsupportedScheme = TransportError("unsupported server address scheme")
+	ErrUnsafeRedirect    = TransportError("alternate address changes the connection scheme")
+)
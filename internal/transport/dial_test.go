@@ -0,0 +1,85 @@
+package transport
+
+import (
+	"crypto/tls"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"Void/internal/framing"
+	"Void/internal/tlsutil"
+)
+
+func TestRedirect(t *testing.T) {
+	tests := []struct {
+		address   string
+		alternate string
+		want      string
+		err       error
+	}{
+		{"chat.example.com:8080", "backup.example.com:9000", "backup.example.com:9000", nil},
+		{"chat.example.com:8080", "tcp://backup.example.com:9000", "backup.example.com:9000", nil},
+		{"tcp://chat.example.com:8080", "backup.example.com:9000", "tcp://backup.example.com:9000", nil},
+		{"tls://chat.example.com:8443?pin=ab:cd", "backup.example.com:9443", "tls://backup.example.com:9443?pin=ab:cd", nil},
+		{"tls://chat.example.com:8443?pin=ab:cd", "tls://backup.example.com:9443?pin=ef", "tls://backup.example.com:9443?pin=ab:cd", nil},
+		{"wss://chat.example.com/ws?pin=ab", "backup.example.com", "wss://backup.example.com/ws?pin=ab", nil},
+		{"tls://chat.example.com:8443?pin=ab", "tcp://backup.example.com:9000", "", ErrUnsafeRedirect},
+		{"tls://chat.example.com:8443", "ws://backup.example.com", "", ErrUnsafeRedirect},
+		{"wss://chat.example.com/ws", "ws://backup.example.com/ws", "", ErrUnsafeRedirect},
+		{"chat.example.com:8080", "tls://backup.example.com:9443", "", ErrUnsafeRedirect},
+		{"tls://chat.example.com:8443", "tls://", "", ErrUnsafeRedirect},
+	}
+	for _, tt := range tests {
+		got, err := Redirect(tt.address, tt.alternate)
+		if got != tt.want || err != tt.err {
+			t.Errorf("Redirect(%q, %q) = %q, %v, want %q, %v", tt.address, tt.alternate, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestDialPinnedTLS(t *testing.T) {
+	dir := t.TempDir()
+	cert, err := tlsutil.LoadOrCreateSelfSigned(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), []string{"localhost"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", tlsutil.ServerConfig(cert))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				stream := NewStream(conn, framing.DefaultMaxFrameSize)
+				defer stream.Close()
+				if frame, err := stream.ReadFrame(); err == nil {
+					stream.WriteFrame(frame)
+				}
+			}()
+		}
+	}()
+
+	fingerprint := tlsutil.Fingerprint(cert.Certificate[0])
+	conn, err := Dial("tls://"+listener.Addr().String()+"?pin="+strings.ToUpper(fingerprint), framing.DefaultMaxFrameSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := conn.WriteFrame([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	if frame, err := conn.ReadFrame(); err != nil || string(frame) != "ping" {
+		t.Fatalf("ReadFrame = %q, %v", frame, err)
+	}
+
+	wrong := strings.Repeat("00", len(fingerprint)/2)
+	if _, err := Dial("tls://"+listener.Addr().String()+"?pin="+wrong, framing.DefaultMaxFrameSize); !errors.Is(err, tlsutil.ErrPinMismatch) {
+		t.Fatalf("wrong pin: err = %v, want %v", err, tlsutil.ErrPinMismatch)
+	}
+}
@@ -0,0 +1,66 @@
+package transport
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+func ParseNetworks(values []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("%q is not an IP address or CIDR range", value)
+			}
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an IP address or CIDR range", value)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+func ForwardedAddr(r *http.Request, trusted []*net.IPNet) net.Addr {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil || !contains(trusted, net.ParseIP(host)) {
+		return nil
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			return nil
+		}
+		if !contains(trusted, ip) {
+			return &net.TCPAddr{IP: ip}
+		}
+	}
+	return nil
+}
+
+func contains(networks []*net.IPNet, ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
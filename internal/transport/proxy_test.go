@@ -0,0 +1,67 @@
+package transport
+
+import (
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestParseNetworks(t *testing.T) {
+	networks, err := ParseNetworks([]string{"10.0.0.1", "192.168.0.0/16", "::1", "fd00::/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ip := range []string{"10.0.0.1", "192.168.4.5", "::1", "fd12::1"} {
+		if !contains(networks, net.ParseIP(ip)) {
+			t.Errorf("%s not contained", ip)
+		}
+	}
+	for _, ip := range []string{"10.0.0.2", "192.169.0.1", "::2", "fe80::1"} {
+		if contains(networks, net.ParseIP(ip)) {
+			t.Errorf("%s contained", ip)
+		}
+	}
+
+	for _, value := range []string{"", "proxy", "10.0.0.1/33", "10.0.0/8", "10.0.0.1:80"} {
+		if _, err := ParseNetworks([]string{value}); err == nil {
+			t.Errorf("ParseNetworks(%q) succeeded", value)
+		}
+	}
+}
+
+func TestForwardedAddr(t *testing.T) {
+	trusted, _ := ParseNetworks([]string{"10.0.0.0/8"})
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded []string
+		want      string
+	}{
+		{"untrusted peer", "203.0.113.9:4000", []string{"198.51.100.1"}, ""},
+		{"no header", "10.0.0.1:4000", nil, ""},
+		{"single hop", "10.0.0.1:4000", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"spoofed prefix", "10.0.0.1:4000", []string{"1.2.3.4, 198.51.100.1"}, "198.51.100.1"},
+		{"trusted chain", "10.0.0.1:4000", []string{"198.51.100.1, 10.0.0.7"}, "198.51.100.1"},
+		{"repeated headers", "10.0.0.1:4000", []string{"1.2.3.4", "198.51.100.1"}, "198.51.100.1"},
+		{"all trusted", "10.0.0.1:4000", []string{"10.0.0.2, 10.0.0.3"}, ""},
+		{"garbage hop", "10.0.0.1:4000", []string{"198.51.100.1, unknown"}, ""},
+		{"ipv6", "10.0.0.1:4000", []string{"2001:db8::1"}, "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &http.Request{RemoteAddr: tt.remote, Header: http.Header{}}
+			for _, value := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+
+			addr := ForwardedAddr(r, trusted)
+			got := ""
+			if addr != nil {
+				got = addr.(*net.TCPAddr).IP.String()
+			}
+			if got != tt.want {
+				t.Fatalf("ForwardedAddr = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package transport
+
+import (
+	"net"
+	"time"
+
+	"Void/internal/framing"
+)
+
+type Conn interface {
+	ReadFrame() ([]byte, error)
+	WriteFrame(data []byte) error
+	SetReadDeadline(t time.Time) error
+	SetWriteDeadline(t time.Time) error
+	RemoteAddr() net.Addr
+	Close() error
+}
+
+type streamConn struct {
+	conn   net.Conn
+	reader *framing.Reader
+	writer *framing.Writer
+}
+
+func NewStream(conn net.Conn, maxFrameSize int) Conn {
+	return &streamConn{
+		conn:   conn,
+		reader: framing.NewReader(conn, maxFrameSize),
+		writer: framing.NewWriter(conn, maxFrameSize),
+	}
+}
+
+func (c *streamConn) ReadFrame() ([]byte, error) {
+	return c.reader.ReadFrame()
+}
+
+func (c *streamConn) WriteFrame(data []byte) error {
+	return c.writer.WriteFrame(data)
+}
+
+func (c *streamConn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+func (c *streamConn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+func (c *streamConn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+func (c *streamConn) Close() error {
+	return c.conn.Close()
+}
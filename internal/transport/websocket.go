@@ -0,0 +1,109 @@
+package transport
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"Void/internal/framing"
+
+	"github.com/gorilla/websocket"
+)
+
+const WebSocketPath = "/ws"
+
+type webSocketConn struct {
+	conn         *websocket.Conn
+	remoteAddr   net.Addr
+	maxFrameSize int
+	writeMu      sync.Mutex
+}
+
+func NewWebSocket(conn *websocket.Conn, maxFrameSize int) Conn {
+	if maxFrameSize <= 0 {
+		maxFrameSize = framing.DefaultMaxFrameSize
+	}
+	conn.SetReadLimit(int64(maxFrameSize))
+	return &webSocketConn{
+		conn:         conn,
+		remoteAddr:   conn.RemoteAddr(),
+		maxFrameSize: maxFrameSize,
+	}
+}
+
+func (c *webSocketConn) ReadFrame() ([]byte, error) {
+	for {
+		messageType, data, err := c.conn.ReadMessage()
+		if err != nil {
+			if err == websocket.ErrReadLimit {
+				return nil, framing.ErrFrameTooLarge
+			}
+			return nil, err
+		}
+		if messageType == websocket.BinaryMessage {
+			return data, nil
+		}
+	}
+}
+
+func (c *webSocketConn) WriteFrame(data []byte) error {
+	if len(data) > c.maxFrameSize {
+		return framing.ErrFrameTooLarge
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteMessage(websocket.BinaryMessage, data)
+}
+
+func (c *webSocketConn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+func (c *webSocketConn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+func (c *webSocketConn) RemoteAddr() net.Addr {
+	return c.remoteAddr
+}
+
+func (c *webSocketConn) Close() error {
+	return c.conn.Close()
+}
+
+type WebSocketHandler struct {
+	upgrader     websocket.Upgrader
+	maxFrameSize int
+	serve        func(Conn)
+	clientAddr   func(*http.Request) net.Addr
+}
+
+func NewWebSocketHandler(maxFrameSize int, serve func(Conn)) *WebSocketHandler {
+	return &WebSocketHandler{
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(*http.Request) bool { return true },
+		},
+		maxFrameSize: maxFrameSize,
+		serve:        serve,
+	}
+}
+
+func (h *WebSocketHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	ws := NewWebSocket(conn, h.maxFrameSize).(*webSocketConn)
+	if h.clientAddr != nil {
+		if addr := h.clientAddr(r); addr != nil {
+			ws.remoteAddr = addr
+		}
+	}
+	h.serve(ws)
+}
+
+func (h *WebSocketHandler) SetClientAddr(fn func(*http.Request) net.Addr) {
+	h.clientAddr = fn
+}
@@ -21,6 +21,339 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type CipherSuite int32
+
+const (
+	CipherSuite_BOX        CipherSuite = 0
+	CipherSuite_RATCHET    CipherSuite = 1
+	CipherSuite_SENDER_KEY CipherSuite = 2
+)
+
+// Enum value maps for CipherSuite.
+var (
+	CipherSuite_name = map[int32]string{
+		0: "BOX",
+		1: "RATCHET",
+		2: "SENDER_KEY",
+	}
+	CipherSuite_value = map[string]int32{
+		"BOX":        0,
+		"RATCHET":    1,
+		"SENDER_KEY": 2,
+	}
+)
+
+func (x CipherSuite) Enum() *CipherSuite {
+	p := new(CipherSuite)
+	*p = x
+	return p
+}
+
+func (x CipherSuite) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CipherSuite) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_chat_proto_enumTypes[0].Descriptor()
+}
+
+func (CipherSuite) Type() protoreflect.EnumType {
+	return &file_proto_chat_proto_enumTypes[0]
+}
+
+func (x CipherSuite) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CipherSuite.Descriptor instead.
+func (CipherSuite) EnumDescriptor() ([]byte, []int) {
+	return file_proto_chat_proto_rawDescGZIP(), []int{0}
+}
+
+type DeliveryStatus int32
+
+const (
+	DeliveryStatus_DELIVERED         DeliveryStatus = 0
+	DeliveryStatus_UNKNOWN_RECIPIENT DeliveryStatus = 1
+	DeliveryStatus_DEPARTED          DeliveryStatus = 2
+	DeliveryStatus_DROPPED           DeliveryStatus = 3
+	DeliveryStatus_QUEUED            DeliveryStatus = 4
+)
+
+// Enum value maps for DeliveryStatus.
+var (
+	DeliveryStatus_name = map[int32]string{
+		0: "DELIVERED",
+		1: "UNKNOWN_RECIPIENT",
+		2: "DEPARTED",
+		3: "DROPPED",
+		4: "QUEUED",
+	}
+	DeliveryStatus_value = map[string]int32{
+		"DELIVERED":         0,
+		"UNKNOWN_RECIPIENT": 1,
+		"DEPARTED":          2,
+		"DROPPED":           3,
+		"QUEUED":            4,
+	}
+)
+
+func (x DeliveryStatus) Enum() *DeliveryStatus {
+	p := new(DeliveryStatus)
+	*p = x
+	return p
+}
+
+func (x DeliveryStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DeliveryStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_chat_proto_enumTypes[1].Descriptor()
+}
+
+func (DeliveryStatus) Type() protoreflect.EnumType {
+	return &file_proto_chat_proto_enumTypes[1]
+}
+
+func (x DeliveryStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DeliveryStatus.Descriptor instead.
+func (DeliveryStatus) EnumDescriptor() ([]byte, []int) {
+	return file_proto_chat_proto_rawDescGZIP(), []int{1}
+}
+
+type LeaveReason int32
+
+const (
+	LeaveReason_LEFT    LeaveReason = 0
+	LeaveReason_TIMEOUT LeaveReason = 1
+)
+
+// Enum value maps for LeaveReason.
+var (
+	LeaveReason_name = map[int32]string{
+		0: "LEFT",
+		1: "TIMEOUT",
+	}
+	LeaveReason_value = map[string]int32{
+		"LEFT":    0,
+		"TIMEOUT": 1,
+	}
+)
+
+func (x LeaveReason) Enum() *LeaveReason {
+	p := new(LeaveReason)
+	*p = x
+	return p
+}
+
+func (x LeaveReason) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (LeaveReason) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_chat_proto_enumTypes[2].Descriptor()
+}
+
+func (LeaveReason) Type() protoreflect.EnumType {
+	return &file_proto_chat_proto_enumTypes[2]
+}
+
+func (x LeaveReason) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use LeaveReason.Descriptor instead.
+func (LeaveReason) EnumDescriptor() ([]byte, []int) {
+	return file_proto_chat_proto_rawDescGZIP(), []int{2}
+}
+
+type RateLimitScope int32
+
+const (
+	RateLimitScope_CONNECTION RateLimitScope = 0
+	RateLimitScope_IP         RateLimitScope = 1
+	RateLimitScope_ROOM       RateLimitScope = 2
+)
+
+// Enum value maps for RateLimitScope.
+var (
+	RateLimitScope_name = map[int32]string{
+		0: "CONNECTION",
+		1: "IP",
+		2: "ROOM",
+	}
+	RateLimitScope_value = map[string]int32{
+		"CONNECTION": 0,
+		"IP":         1,
+		"ROOM":       2,
+	}
+)
+
+func (x RateLimitScope) Enum() *RateLimitScope {
+	p := new(RateLimitScope)
+	*p = x
+	return p
+}
+
+func (x RateLimitScope) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RateLimitScope) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_chat_proto_enumTypes[3].Descriptor()
+}
+
+func (RateLimitScope) Type() protoreflect.EnumType {
+	return &file_proto_chat_proto_enumTypes[3]
+}
+
+func (x RateLimitScope) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RateLimitScope.Descriptor instead.
+func (RateLimitScope) EnumDescriptor() ([]byte, []int) {
+	return file_proto_chat_proto_rawDescGZIP(), []int{3}
+}
+
+type HelloError int32
+
+const (
+	HelloError_HELLO_REQUIRED      HelloError = 0
+	HelloError_UNSUPPORTED_VERSION HelloError = 1
+	HelloError_UNSUPPORTED_FEATURE HelloError = 2
+)
+
+// Enum value maps for HelloError.
+var (
+	HelloError_name = map[int32]string{
+		0: "HELLO_REQUIRED",
+		1: "UNSUPPORTED_VERSION",
+		2: "UNSUPPORTED_FEATURE",
+	}
+	HelloError_value = map[string]int32{
+		"HELLO_REQUIRED":      0,
+		"UNSUPPORTED_VERSION": 1,
+		"UNSUPPORTED_FEATURE": 2,
+	}
+)
+
+func (x HelloError) Enum() *HelloError {
+	p := new(HelloError)
+	*p = x
+	return p
+}
+
+func (x HelloError) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (HelloError) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_chat_proto_enumTypes[4].Descriptor()
+}
+
+func (HelloError) Type() protoreflect.EnumType {
+	return &file_proto_chat_proto_enumTypes[4]
+}
+
+func (x HelloError) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use HelloError.Descriptor instead.
+func (HelloError) EnumDescriptor() ([]byte, []int) {
+	return file_proto_chat_proto_rawDescGZIP(), []int{4}
+}
+
+type ErrorCode int32
+
+const (
+	ErrorCode_OK               ErrorCode = 0
+	ErrorCode_DRAINING         ErrorCode = 1
+	ErrorCode_TOO_MANY_ROOMS   ErrorCode = 2
+	ErrorCode_INVALID_VERIFIER ErrorCode = 3
+	ErrorCode_INVALID_PASSWORD ErrorCode = 4
+	ErrorCode_ROOM_FULL        ErrorCode = 5
+	ErrorCode_RATE_LIMITED     ErrorCode = 6
+	ErrorCode_NOT_IN_ROOM      ErrorCode = 7
+	ErrorCode_INVALID_MESSAGE  ErrorCode = 8
+	ErrorCode_MALFORMED_FRAME  ErrorCode = 9
+	ErrorCode_ROOM_NOT_FOUND   ErrorCode = 10
+	ErrorCode_ROOM_EXISTS      ErrorCode = 11
+	ErrorCode_ALREADY_IN_ROOM  ErrorCode = 12
+	ErrorCode_UNSUPPORTED      ErrorCode = 13
+	ErrorCode_BAD_REQUEST      ErrorCode = 14
+)
+
+// Enum value maps for ErrorCode.
+var (
+	ErrorCode_name = map[int32]string{
+		0:  "OK",
+		1:  "DRAINING",
+		2:  "TOO_MANY_ROOMS",
+		3:  "INVALID_VERIFIER",
+		4:  "INVALID_PASSWORD",
+		5:  "ROOM_FULL",
+		6:  "RATE_LIMITED",
+		7:  "NOT_IN_ROOM",
+		8:  "INVALID_MESSAGE",
+		9:  "MALFORMED_FRAME",
+		10: "ROOM_NOT_FOUND",
+		11: "ROOM_EXISTS",
+		12: "ALREADY_IN_ROOM",
+		13: "UNSUPPORTED",
+		14: "BAD_REQUEST",
+	}
+	ErrorCode_value = map[string]int32{
+		"OK":               0,
+		"DRAINING":         1,
+		"TOO_MANY_ROOMS":   2,
+		"INVALID_VERIFIER": 3,
+		"INVALID_PASSWORD": 4,
+		"ROOM_FULL":        5,
+		"RATE_LIMITED":     6,
+		"NOT_IN_ROOM":      7,
+		"INVALID_MESSAGE":  8,
+		"MALFORMED_FRAME":  9,
+		"ROOM_NOT_FOUND":   10,
+		"ROOM_EXISTS":      11,
+		"ALREADY_IN_ROOM":  12,
+		"UNSUPPORTED":      13,
+		"BAD_REQUEST":      14,
+	}
+)
+
+func (x ErrorCode) Enum() *ErrorCode {
+	p := new(ErrorCode)
+	*p = x
+	return p
+}
+
+func (x ErrorCode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ErrorCode) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_chat_proto_enumTypes[5].Descriptor()
+}
+
+func (ErrorCode) Type() protoreflect.EnumType {
+	return &file_proto_chat_proto_enumTypes[5]
+}
+
+func (x ErrorCode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ErrorCode.Descriptor instead.
+func (ErrorCode) EnumDescriptor() ([]byte, []int) {
+	return file_proto_chat_proto_rawDescGZIP(), []int{5}
+}
+
 type Message struct {
 	state            protoimpl.MessageState `protogen:"open.v1"`
 	Id               string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -106,14 +439,20 @@ func (x *Message) GetEncryptedContent() []byte {
 }
 
 type RoomRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	RoomId        string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
-	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	Username      string                 `protobuf:"bytes,3,opt,name=username,proto3" json:"username,omitempty"`
-	PublicKey     []byte                 `protobuf:"bytes,4,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
-	Password      string                 `protobuf:"bytes,5,opt,name=password,proto3" json:"password,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	RoomId           string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	UserId           string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Username         string                 `protobuf:"bytes,3,opt,name=username,proto3" json:"username,omitempty"`
+	PublicKey        []byte                 `protobuf:"bytes,4,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	IdentityKey      []byte                 `protobuf:"bytes,6,opt,name=identity_key,json=identityKey,proto3" json:"identity_key,omitempty"`
+	KeySignature     []byte                 `protobuf:"bytes,7,opt,name=key_signature,json=keySignature,proto3" json:"key_signature,omitempty"`
+	Capabilities     []string               `protobuf:"bytes,8,rep,name=capabilities,proto3" json:"capabilities,omitempty"`
+	PasswordVerifier []byte                 `protobuf:"bytes,9,opt,name=password_verifier,json=passwordVerifier,proto3" json:"password_verifier,omitempty"`
+	PasswordProof    []byte                 `protobuf:"bytes,10,opt,name=password_proof,json=passwordProof,proto3" json:"password_proof,omitempty"`
+	ResumeToken      []byte                 `protobuf:"bytes,11,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	MembershipProof  []byte                 `protobuf:"bytes,12,opt,name=membership_proof,json=membershipProof,proto3" json:"membership_proof,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
 func (x *RoomRequest) Reset() {
@@ -174,11 +513,53 @@ func (x *RoomRequest) GetPublicKey() []byte {
 	return nil
 }
 
-func (x *RoomRequest) GetPassword() string {
+func (x *RoomRequest) GetIdentityKey() []byte {
 	if x != nil {
-		return x.Password
+		return x.IdentityKey
 	}
-	return ""
+	return nil
+}
+
+func (x *RoomRequest) GetKeySignature() []byte {
+	if x != nil {
+		return x.KeySignature
+	}
+	return nil
+}
+
+func (x *RoomRequest) GetCapabilities() []string {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+func (x *RoomRequest) GetPasswordVerifier() []byte {
+	if x != nil {
+		return x.PasswordVerifier
+	}
+	return nil
+}
+
+func (x *RoomRequest) GetPasswordProof() []byte {
+	if x != nil {
+		return x.PasswordProof
+	}
+	return nil
+}
+
+func (x *RoomRequest) GetResumeToken() []byte {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return nil
+}
+
+func (x *RoomRequest) GetMembershipProof() []byte {
+	if x != nil {
+		return x.MembershipProof
+	}
+	return nil
 }
 
 type RoomResponse struct {
@@ -187,6 +568,8 @@ type RoomResponse struct {
 	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
 	Peers         []*Peer                `protobuf:"bytes,3,rep,name=peers,proto3" json:"peers,omitempty"`
 	UserId        string                 `protobuf:"bytes,4,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	ResumeToken   []byte                 `protobuf:"bytes,5,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	Code          ErrorCode              `protobuf:"varint,6,opt,name=code,proto3,enum=chat.ErrorCode" json:"code,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -249,13 +632,31 @@ func (x *RoomResponse) GetUserId() string {
 	return ""
 }
 
+func (x *RoomResponse) GetResumeToken() []byte {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return nil
+}
+
+func (x *RoomResponse) GetCode() ErrorCode {
+	if x != nil {
+		return x.Code
+	}
+	return ErrorCode_OK
+}
+
 type Peer struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	Username      string                 `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
-	PublicKey     []byte                 `protobuf:"bytes,3,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	UserId          string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Username        string                 `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	PublicKey       []byte                 `protobuf:"bytes,3,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	IdentityKey     []byte                 `protobuf:"bytes,4,opt,name=identity_key,json=identityKey,proto3" json:"identity_key,omitempty"`
+	KeySignature    []byte                 `protobuf:"bytes,5,opt,name=key_signature,json=keySignature,proto3" json:"key_signature,omitempty"`
+	Capabilities    []string               `protobuf:"bytes,6,rep,name=capabilities,proto3" json:"capabilities,omitempty"`
+	MembershipProof []byte                 `protobuf:"bytes,7,opt,name=membership_proof,json=membershipProof,proto3" json:"membership_proof,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *Peer) Reset() {
@@ -309,28 +710,57 @@ func (x *Peer) GetPublicKey() []byte {
 	return nil
 }
 
-type SendMessage struct {
-	state            protoimpl.MessageState `protogen:"open.v1"`
-	RoomId           string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
-	EncryptedContent []byte                 `protobuf:"bytes,2,opt,name=encrypted_content,json=encryptedContent,proto3" json:"encrypted_content,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+func (x *Peer) GetIdentityKey() []byte {
+	if x != nil {
+		return x.IdentityKey
+	}
+	return nil
 }
 
-func (x *SendMessage) Reset() {
-	*x = SendMessage{}
+func (x *Peer) GetKeySignature() []byte {
+	if x != nil {
+		return x.KeySignature
+	}
+	return nil
+}
+
+func (x *Peer) GetCapabilities() []string {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+func (x *Peer) GetMembershipProof() []byte {
+	if x != nil {
+		return x.MembershipProof
+	}
+	return nil
+}
+
+type Envelope struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	RecipientUserId string                 `protobuf:"bytes,1,opt,name=recipient_user_id,json=recipientUserId,proto3" json:"recipient_user_id,omitempty"`
+	Ciphertext      []byte                 `protobuf:"bytes,2,opt,name=ciphertext,proto3" json:"ciphertext,omitempty"`
+	Suite           CipherSuite            `protobuf:"varint,3,opt,name=suite,proto3,enum=chat.CipherSuite" json:"suite,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Envelope) Reset() {
+	*x = Envelope{}
 	mi := &file_proto_chat_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SendMessage) String() string {
+func (x *Envelope) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SendMessage) ProtoMessage() {}
+func (*Envelope) ProtoMessage() {}
 
-func (x *SendMessage) ProtoReflect() protoreflect.Message {
+func (x *Envelope) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_chat_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -342,50 +772,55 @@ func (x *SendMessage) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SendMessage.ProtoReflect.Descriptor instead.
-func (*SendMessage) Descriptor() ([]byte, []int) {
+// Deprecated: Use Envelope.ProtoReflect.Descriptor instead.
+func (*Envelope) Descriptor() ([]byte, []int) {
 	return file_proto_chat_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *SendMessage) GetRoomId() string {
+func (x *Envelope) GetRecipientUserId() string {
 	if x != nil {
-		return x.RoomId
+		return x.RecipientUserId
 	}
 	return ""
 }
 
-func (x *SendMessage) GetEncryptedContent() []byte {
+func (x *Envelope) GetCiphertext() []byte {
 	if x != nil {
-		return x.EncryptedContent
+		return x.Ciphertext
 	}
 	return nil
 }
 
-type ReceiveMessage struct {
-	state            protoimpl.MessageState `protogen:"open.v1"`
-	Id               string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	UserId           string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	Username         string                 `protobuf:"bytes,3,opt,name=username,proto3" json:"username,omitempty"`
-	EncryptedContent []byte                 `protobuf:"bytes,4,opt,name=encrypted_content,json=encryptedContent,proto3" json:"encrypted_content,omitempty"`
-	Timestamp        int64                  `protobuf:"varint,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+func (x *Envelope) GetSuite() CipherSuite {
+	if x != nil {
+		return x.Suite
+	}
+	return CipherSuite_BOX
 }
 
-func (x *ReceiveMessage) Reset() {
-	*x = ReceiveMessage{}
+type SendMessage struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	RoomId          string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	Envelopes       []*Envelope            `protobuf:"bytes,3,rep,name=envelopes,proto3" json:"envelopes,omitempty"`
+	GroupCiphertext []byte                 `protobuf:"bytes,4,opt,name=group_ciphertext,json=groupCiphertext,proto3" json:"group_ciphertext,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *SendMessage) Reset() {
+	*x = SendMessage{}
 	mi := &file_proto_chat_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ReceiveMessage) String() string {
+func (x *SendMessage) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ReceiveMessage) ProtoMessage() {}
+func (*SendMessage) ProtoMessage() {}
 
-func (x *ReceiveMessage) ProtoReflect() protoreflect.Message {
+func (x *SendMessage) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_chat_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -397,74 +832,1084 @@ func (x *ReceiveMessage) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ReceiveMessage.ProtoReflect.Descriptor instead.
-func (*ReceiveMessage) Descriptor() ([]byte, []int) {
+// Deprecated: Use SendMessage.ProtoReflect.Descriptor instead.
+func (*SendMessage) Descriptor() ([]byte, []int) {
 	return file_proto_chat_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *ReceiveMessage) GetId() string {
+func (x *SendMessage) GetRoomId() string {
 	if x != nil {
-		return x.Id
+		return x.RoomId
 	}
 	return ""
 }
 
-func (x *ReceiveMessage) GetUserId() string {
+func (x *SendMessage) GetEnvelopes() []*Envelope {
+	if x != nil {
+		return x.Envelopes
+	}
+	return nil
+}
+
+func (x *SendMessage) GetGroupCiphertext() []byte {
+	if x != nil {
+		return x.GroupCiphertext
+	}
+	return nil
+}
+
+type SenderKeyDistribution struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RoomId        string                 `protobuf:"bytes,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	Envelopes     []*Envelope            `protobuf:"bytes,2,rep,name=envelopes,proto3" json:"envelopes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SenderKeyDistribution) Reset() {
+	*x = SenderKeyDistribution{}
+	mi := &file_proto_chat_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SenderKeyDistribution) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SenderKeyDistribution) ProtoMessage() {}
+
+func (x *SenderKeyDistribution) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SenderKeyDistribution.ProtoReflect.Descriptor instead.
+func (*SenderKeyDistribution) Descriptor() ([]byte, []int) {
+	return file_proto_chat_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SenderKeyDistribution) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+func (x *SenderKeyDistribution) GetEnvelopes() []*Envelope {
+	if x != nil {
+		return x.Envelopes
+	}
+	return nil
+}
+
+type SenderKeyMaterial struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	KeyId         uint32                 `protobuf:"varint,1,opt,name=key_id,json=keyId,proto3" json:"key_id,omitempty"`
+	ChainKey      []byte                 `protobuf:"bytes,2,opt,name=chain_key,json=chainKey,proto3" json:"chain_key,omitempty"`
+	Iteration     uint32                 `protobuf:"varint,3,opt,name=iteration,proto3" json:"iteration,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SenderKeyMaterial) Reset() {
+	*x = SenderKeyMaterial{}
+	mi := &file_proto_chat_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SenderKeyMaterial) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SenderKeyMaterial) ProtoMessage() {}
+
+func (x *SenderKeyMaterial) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SenderKeyMaterial.ProtoReflect.Descriptor instead.
+func (*SenderKeyMaterial) Descriptor() ([]byte, []int) {
+	return file_proto_chat_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SenderKeyMaterial) GetKeyId() uint32 {
+	if x != nil {
+		return x.KeyId
+	}
+	return 0
+}
+
+func (x *SenderKeyMaterial) GetChainKey() []byte {
+	if x != nil {
+		return x.ChainKey
+	}
+	return nil
+}
+
+func (x *SenderKeyMaterial) GetIteration() uint32 {
+	if x != nil {
+		return x.Iteration
+	}
+	return 0
+}
+
+type SenderKeyMessage struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	UserId           string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	EncryptedContent []byte                 `protobuf:"bytes,2,opt,name=encrypted_content,json=encryptedContent,proto3" json:"encrypted_content,omitempty"`
+	Suite            CipherSuite            `protobuf:"varint,3,opt,name=suite,proto3,enum=chat.CipherSuite" json:"suite,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *SenderKeyMessage) Reset() {
+	*x = SenderKeyMessage{}
+	mi := &file_proto_chat_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SenderKeyMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SenderKeyMessage) ProtoMessage() {}
+
+func (x *SenderKeyMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SenderKeyMessage.ProtoReflect.Descriptor instead.
+func (*SenderKeyMessage) Descriptor() ([]byte, []int) {
+	return file_proto_chat_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SenderKeyMessage) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *SenderKeyMessage) GetEncryptedContent() []byte {
+	if x != nil {
+		return x.EncryptedContent
+	}
+	return nil
+}
+
+func (x *SenderKeyMessage) GetSuite() CipherSuite {
+	if x != nil {
+		return x.Suite
+	}
+	return CipherSuite_BOX
+}
+
+type ReceiveMessage struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId           string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Username         string                 `protobuf:"bytes,3,opt,name=username,proto3" json:"username,omitempty"`
+	EncryptedContent []byte                 `protobuf:"bytes,4,opt,name=encrypted_content,json=encryptedContent,proto3" json:"encrypted_content,omitempty"`
+	Timestamp        int64                  `protobuf:"varint,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Suite            CipherSuite            `protobuf:"varint,6,opt,name=suite,proto3,enum=chat.CipherSuite" json:"suite,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ReceiveMessage) Reset() {
+	*x = ReceiveMessage{}
+	mi := &file_proto_chat_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReceiveMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReceiveMessage) ProtoMessage() {}
+
+func (x *ReceiveMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReceiveMessage.ProtoReflect.Descriptor instead.
+func (*ReceiveMessage) Descriptor() ([]byte, []int) {
+	return file_proto_chat_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ReceiveMessage) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ReceiveMessage) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ReceiveMessage) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *ReceiveMessage) GetEncryptedContent() []byte {
+	if x != nil {
+		return x.EncryptedContent
+	}
+	return nil
+}
+
+func (x *ReceiveMessage) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *ReceiveMessage) GetSuite() CipherSuite {
+	if x != nil {
+		return x.Suite
+	}
+	return CipherSuite_BOX
+}
+
+type ServerMessage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*ServerMessage_Message
+	//	*ServerMessage_PeerJoined
+	//	*ServerMessage_PeerLeft
+	//	*ServerMessage_RoomResponse
+	//	*ServerMessage_DeliveryReport
+	//	*ServerMessage_SenderKey
+	//	*ServerMessage_AuthChallenge
+	//	*ServerMessage_Pong
+	//	*ServerMessage_ServerShutdown
+	//	*ServerMessage_RateLimited
+	//	*ServerMessage_MessagesDropped
+	//	*ServerMessage_ServerHello
+	//	*ServerMessage_HelloRejected
+	//	*ServerMessage_ServerError
+	Payload       isServerMessage_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServerMessage) Reset() {
+	*x = ServerMessage{}
+	mi := &file_proto_chat_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServerMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerMessage) ProtoMessage() {}
+
+func (x *ServerMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServerMessage.ProtoReflect.Descriptor instead.
+func (*ServerMessage) Descriptor() ([]byte, []int) {
+	return file_proto_chat_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ServerMessage) GetPayload() isServerMessage_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetMessage() *ReceiveMessage {
+	if x != nil {
+		if x, ok := x.Payload.(*ServerMessage_Message); ok {
+			return x.Message
+		}
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetPeerJoined() *PeerJoined {
+	if x != nil {
+		if x, ok := x.Payload.(*ServerMessage_PeerJoined); ok {
+			return x.PeerJoined
+		}
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetPeerLeft() *PeerLeft {
+	if x != nil {
+		if x, ok := x.Payload.(*ServerMessage_PeerLeft); ok {
+			return x.PeerLeft
+		}
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetRoomResponse() *RoomResponse {
+	if x != nil {
+		if x, ok := x.Payload.(*ServerMessage_RoomResponse); ok {
+			return x.RoomResponse
+		}
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetDeliveryReport() *DeliveryReport {
+	if x != nil {
+		if x, ok := x.Payload.(*ServerMessage_DeliveryReport); ok {
+			return x.DeliveryReport
+		}
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetSenderKey() *SenderKeyMessage {
+	if x != nil {
+		if x, ok := x.Payload.(*ServerMessage_SenderKey); ok {
+			return x.SenderKey
+		}
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetAuthChallenge() *AuthChallenge {
+	if x != nil {
+		if x, ok := x.Payload.(*ServerMessage_AuthChallenge); ok {
+			return x.AuthChallenge
+		}
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetPong() *Pong {
+	if x != nil {
+		if x, ok := x.Payload.(*ServerMessage_Pong); ok {
+			return x.Pong
+		}
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetServerShutdown() *ServerShutdown {
+	if x != nil {
+		if x, ok := x.Payload.(*ServerMessage_ServerShutdown); ok {
+			return x.ServerShutdown
+		}
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetRateLimited() *RateLimited {
+	if x != nil {
+		if x, ok := x.Payload.(*ServerMessage_RateLimited); ok {
+			return x.RateLimited
+		}
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetMessagesDropped() *MessagesDropped {
+	if x != nil {
+		if x, ok := x.Payload.(*ServerMessage_MessagesDropped); ok {
+			return x.MessagesDropped
+		}
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetServerHello() *ServerHello {
+	if x != nil {
+		if x, ok := x.Payload.(*ServerMessage_ServerHello); ok {
+			return x.ServerHello
+		}
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetHelloRejected() *HelloRejected {
+	if x != nil {
+		if x, ok := x.Payload.(*ServerMessage_HelloRejected); ok {
+			return x.HelloRejected
+		}
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetServerError() *ServerError {
+	if x != nil {
+		if x, ok := x.Payload.(*ServerMessage_ServerError); ok {
+			return x.ServerError
+		}
+	}
+	return nil
+}
+
+type isServerMessage_Payload interface {
+	isServerMessage_Payload()
+}
+
+type ServerMessage_Message struct {
+	Message *ReceiveMessage `protobuf:"bytes,1,opt,name=message,proto3,oneof"`
+}
+
+type ServerMessage_PeerJoined struct {
+	PeerJoined *PeerJoined `protobuf:"bytes,2,opt,name=peer_joined,json=peerJoined,proto3,oneof"`
+}
+
+type ServerMessage_PeerLeft struct {
+	PeerLeft *PeerLeft `protobuf:"bytes,3,opt,name=peer_left,json=peerLeft,proto3,oneof"`
+}
+
+type ServerMessage_RoomResponse struct {
+	RoomResponse *RoomResponse `protobuf:"bytes,4,opt,name=room_response,json=roomResponse,proto3,oneof"`
+}
+
+type ServerMessage_DeliveryReport struct {
+	DeliveryReport *DeliveryReport `protobuf:"bytes,5,opt,name=delivery_report,json=deliveryReport,proto3,oneof"`
+}
+
+type ServerMessage_SenderKey struct {
+	SenderKey *SenderKeyMessage `protobuf:"bytes,6,opt,name=sender_key,json=senderKey,proto3,oneof"`
+}
+
+type ServerMessage_AuthChallenge struct {
+	AuthChallenge *AuthChallenge `protobuf:"bytes,7,opt,name=auth_challenge,json=authChallenge,proto3,oneof"`
+}
+
+type ServerMessage_Pong struct {
+	Pong *Pong `protobuf:"bytes,8,opt,name=pong,proto3,oneof"`
+}
+
+type ServerMessage_ServerShutdown struct {
+	ServerShutdown *ServerShutdown `protobuf:"bytes,9,opt,name=server_shutdown,json=serverShutdown,proto3,oneof"`
+}
+
+type ServerMessage_RateLimited struct {
+	RateLimited *RateLimited `protobuf:"bytes,10,opt,name=rate_limited,json=rateLimited,proto3,oneof"`
+}
+
+type ServerMessage_MessagesDropped struct {
+	MessagesDropped *MessagesDropped `protobuf:"bytes,11,opt,name=messages_dropped,json=messagesDropped,proto3,oneof"`
+}
+
+type ServerMessage_ServerHello struct {
+	ServerHello *ServerHello `protobuf:"bytes,12,opt,name=server_hello,json=serverHello,proto3,oneof"`
+}
+
+type ServerMessage_HelloRejected struct {
+	HelloRejected *HelloRejected `protobuf:"bytes,13,opt,name=hello_rejected,json=helloRejected,proto3,oneof"`
+}
+
+type ServerMessage_ServerError struct {
+	ServerError *ServerError `protobuf:"bytes,14,opt,name=server_error,json=serverError,proto3,oneof"`
+}
+
+func (*ServerMessage_Message) isServerMessage_Payload() {}
+
+func (*ServerMessage_PeerJoined) isServerMessage_Payload() {}
+
+func (*ServerMessage_PeerLeft) isServerMessage_Payload() {}
+
+func (*ServerMessage_RoomResponse) isServerMessage_Payload() {}
+
+func (*ServerMessage_DeliveryReport) isServerMessage_Payload() {}
+
+func (*ServerMessage_SenderKey) isServerMessage_Payload() {}
+
+func (*ServerMessage_AuthChallenge) isServerMessage_Payload() {}
+
+func (*ServerMessage_Pong) isServerMessage_Payload() {}
+
+func (*ServerMessage_ServerShutdown) isServerMessage_Payload() {}
+
+func (*ServerMessage_RateLimited) isServerMessage_Payload() {}
+
+func (*ServerMessage_MessagesDropped) isServerMessage_Payload() {}
+
+func (*ServerMessage_ServerHello) isServerMessage_Payload() {}
+
+func (*ServerMessage_HelloRejected) isServerMessage_Payload() {}
+
+func (*ServerMessage_ServerError) isServerMessage_Payload() {}
+
+type AuthChallenge struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Nonce         []byte                 `protobuf:"bytes,1,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuthChallenge) Reset() {
+	*x = AuthChallenge{}
+	mi := &file_proto_chat_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuthChallenge) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuthChallenge) ProtoMessage() {}
+
+func (x *AuthChallenge) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuthChallenge.ProtoReflect.Descriptor instead.
+func (*AuthChallenge) Descriptor() ([]byte, []int) {
+	return file_proto_chat_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *AuthChallenge) GetNonce() []byte {
+	if x != nil {
+		return x.Nonce
+	}
+	return nil
+}
+
+type DeliveryResult struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	RecipientUserId string                 `protobuf:"bytes,1,opt,name=recipient_user_id,json=recipientUserId,proto3" json:"recipient_user_id,omitempty"`
+	Status          DeliveryStatus         `protobuf:"varint,2,opt,name=status,proto3,enum=chat.DeliveryStatus" json:"status,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *DeliveryResult) Reset() {
+	*x = DeliveryResult{}
+	mi := &file_proto_chat_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeliveryResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeliveryResult) ProtoMessage() {}
+
+func (x *DeliveryResult) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeliveryResult.ProtoReflect.Descriptor instead.
+func (*DeliveryResult) Descriptor() ([]byte, []int) {
+	return file_proto_chat_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *DeliveryResult) GetRecipientUserId() string {
+	if x != nil {
+		return x.RecipientUserId
+	}
+	return ""
+}
+
+func (x *DeliveryResult) GetStatus() DeliveryStatus {
+	if x != nil {
+		return x.Status
+	}
+	return DeliveryStatus_DELIVERED
+}
+
+type DeliveryReport struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MessageId     string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Results       []*DeliveryResult      `protobuf:"bytes,2,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeliveryReport) Reset() {
+	*x = DeliveryReport{}
+	mi := &file_proto_chat_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeliveryReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeliveryReport) ProtoMessage() {}
+
+func (x *DeliveryReport) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeliveryReport.ProtoReflect.Descriptor instead.
+func (*DeliveryReport) Descriptor() ([]byte, []int) {
+	return file_proto_chat_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *DeliveryReport) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *DeliveryReport) GetResults() []*DeliveryResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type PeerJoined struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	UserId          string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Username        string                 `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	PublicKey       []byte                 `protobuf:"bytes,3,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	IdentityKey     []byte                 `protobuf:"bytes,4,opt,name=identity_key,json=identityKey,proto3" json:"identity_key,omitempty"`
+	KeySignature    []byte                 `protobuf:"bytes,5,opt,name=key_signature,json=keySignature,proto3" json:"key_signature,omitempty"`
+	Capabilities    []string               `protobuf:"bytes,6,rep,name=capabilities,proto3" json:"capabilities,omitempty"`
+	MembershipProof []byte                 `protobuf:"bytes,7,opt,name=membership_proof,json=membershipProof,proto3" json:"membership_proof,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *PeerJoined) Reset() {
+	*x = PeerJoined{}
+	mi := &file_proto_chat_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PeerJoined) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PeerJoined) ProtoMessage() {}
+
+func (x *PeerJoined) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PeerJoined.ProtoReflect.Descriptor instead.
+func (*PeerJoined) Descriptor() ([]byte, []int) {
+	return file_proto_chat_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *PeerJoined) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *PeerJoined) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *PeerJoined) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+func (x *PeerJoined) GetIdentityKey() []byte {
+	if x != nil {
+		return x.IdentityKey
+	}
+	return nil
+}
+
+func (x *PeerJoined) GetKeySignature() []byte {
+	if x != nil {
+		return x.KeySignature
+	}
+	return nil
+}
+
+func (x *PeerJoined) GetCapabilities() []string {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+func (x *PeerJoined) GetMembershipProof() []byte {
+	if x != nil {
+		return x.MembershipProof
+	}
+	return nil
+}
+
+type PeerLeft struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Reason        LeaveReason            `protobuf:"varint,2,opt,name=reason,proto3,enum=chat.LeaveReason" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PeerLeft) Reset() {
+	*x = PeerLeft{}
+	mi := &file_proto_chat_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PeerLeft) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PeerLeft) ProtoMessage() {}
+
+func (x *PeerLeft) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PeerLeft.ProtoReflect.Descriptor instead.
+func (*PeerLeft) Descriptor() ([]byte, []int) {
+	return file_proto_chat_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *PeerLeft) GetUserId() string {
 	if x != nil {
 		return x.UserId
 	}
-	return ""
+	return ""
+}
+
+func (x *PeerLeft) GetReason() LeaveReason {
+	if x != nil {
+		return x.Reason
+	}
+	return LeaveReason_LEFT
+}
+
+type Ping struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Nonce         uint64                 `protobuf:"varint,1,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Ping) Reset() {
+	*x = Ping{}
+	mi := &file_proto_chat_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ping) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ping) ProtoMessage() {}
+
+func (x *Ping) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ping.ProtoReflect.Descriptor instead.
+func (*Ping) Descriptor() ([]byte, []int) {
+	return file_proto_chat_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *Ping) GetNonce() uint64 {
+	if x != nil {
+		return x.Nonce
+	}
+	return 0
+}
+
+type Pong struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Nonce         uint64                 `protobuf:"varint,1,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Pong) Reset() {
+	*x = Pong{}
+	mi := &file_proto_chat_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Pong) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Pong) ProtoMessage() {}
+
+func (x *Pong) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Pong.ProtoReflect.Descriptor instead.
+func (*Pong) Descriptor() ([]byte, []int) {
+	return file_proto_chat_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *Pong) GetNonce() uint64 {
+	if x != nil {
+		return x.Nonce
+	}
+	return 0
+}
+
+type ServerShutdown struct {
+	state                 protoimpl.MessageState `protogen:"open.v1"`
+	Reason                string                 `protobuf:"bytes,1,opt,name=reason,proto3" json:"reason,omitempty"`
+	ReconnectAfterSeconds uint32                 `protobuf:"varint,2,opt,name=reconnect_after_seconds,json=reconnectAfterSeconds,proto3" json:"reconnect_after_seconds,omitempty"`
+	AlternateAddress      string                 `protobuf:"bytes,3,opt,name=alternate_address,json=alternateAddress,proto3" json:"alternate_address,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *ServerShutdown) Reset() {
+	*x = ServerShutdown{}
+	mi := &file_proto_chat_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServerShutdown) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerShutdown) ProtoMessage() {}
+
+func (x *ServerShutdown) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServerShutdown.ProtoReflect.Descriptor instead.
+func (*ServerShutdown) Descriptor() ([]byte, []int) {
+	return file_proto_chat_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ServerShutdown) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *ServerShutdown) GetReconnectAfterSeconds() uint32 {
+	if x != nil {
+		return x.ReconnectAfterSeconds
+	}
+	return 0
 }
 
-func (x *ReceiveMessage) GetUsername() string {
+func (x *ServerShutdown) GetAlternateAddress() string {
 	if x != nil {
-		return x.Username
+		return x.AlternateAddress
 	}
 	return ""
 }
 
-func (x *ReceiveMessage) GetEncryptedContent() []byte {
+type RateLimited struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Scope         RateLimitScope         `protobuf:"varint,1,opt,name=scope,proto3,enum=chat.RateLimitScope" json:"scope,omitempty"`
+	RetryAfterMs  uint32                 `protobuf:"varint,2,opt,name=retry_after_ms,json=retryAfterMs,proto3" json:"retry_after_ms,omitempty"`
+	RequestId     string                 `protobuf:"bytes,3,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RateLimited) Reset() {
+	*x = RateLimited{}
+	mi := &file_proto_chat_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RateLimited) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RateLimited) ProtoMessage() {}
+
+func (x *RateLimited) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_proto_msgTypes[19]
 	if x != nil {
-		return x.EncryptedContent
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-func (x *ReceiveMessage) GetTimestamp() int64 {
+// Deprecated: Use RateLimited.ProtoReflect.Descriptor instead.
+func (*RateLimited) Descriptor() ([]byte, []int) {
+	return file_proto_chat_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *RateLimited) GetScope() RateLimitScope {
 	if x != nil {
-		return x.Timestamp
+		return x.Scope
+	}
+	return RateLimitScope_CONNECTION
+}
+
+func (x *RateLimited) GetRetryAfterMs() uint32 {
+	if x != nil {
+		return x.RetryAfterMs
 	}
 	return 0
 }
 
-type ServerMessage struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	// Types that are valid to be assigned to Payload:
-	//
-	//	*ServerMessage_Message
-	//	*ServerMessage_PeerJoined
-	//	*ServerMessage_PeerLeft
-	//	*ServerMessage_RoomResponse
-	Payload       isServerMessage_Payload `protobuf_oneof:"payload"`
+func (x *RateLimited) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+type MessagesDropped struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Count         uint32                 `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ServerMessage) Reset() {
-	*x = ServerMessage{}
-	mi := &file_proto_chat_proto_msgTypes[6]
+func (x *MessagesDropped) Reset() {
+	*x = MessagesDropped{}
+	mi := &file_proto_chat_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ServerMessage) String() string {
+func (x *MessagesDropped) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ServerMessage) ProtoMessage() {}
+func (*MessagesDropped) ProtoMessage() {}
 
-func (x *ServerMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_chat_proto_msgTypes[6]
+func (x *MessagesDropped) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_proto_msgTypes[20]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -475,106 +1920,177 @@ func (x *ServerMessage) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ServerMessage.ProtoReflect.Descriptor instead.
-func (*ServerMessage) Descriptor() ([]byte, []int) {
-	return file_proto_chat_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use MessagesDropped.ProtoReflect.Descriptor instead.
+func (*MessagesDropped) Descriptor() ([]byte, []int) {
+	return file_proto_chat_proto_rawDescGZIP(), []int{20}
 }
 
-func (x *ServerMessage) GetPayload() isServerMessage_Payload {
+func (x *MessagesDropped) GetCount() uint32 {
 	if x != nil {
-		return x.Payload
+		return x.Count
 	}
-	return nil
+	return 0
 }
 
-func (x *ServerMessage) GetMessage() *ReceiveMessage {
+type ServerHello struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MinVersion    uint32                 `protobuf:"varint,1,opt,name=min_version,json=minVersion,proto3" json:"min_version,omitempty"`
+	MaxVersion    uint32                 `protobuf:"varint,2,opt,name=max_version,json=maxVersion,proto3" json:"max_version,omitempty"`
+	Features      []string               `protobuf:"bytes,3,rep,name=features,proto3" json:"features,omitempty"`
+	MaxFrameSize  uint32                 `protobuf:"varint,4,opt,name=max_frame_size,json=maxFrameSize,proto3" json:"max_frame_size,omitempty"`
+	MaxRoomSize   uint32                 `protobuf:"varint,5,opt,name=max_room_size,json=maxRoomSize,proto3" json:"max_room_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServerHello) Reset() {
+	*x = ServerHello{}
+	mi := &file_proto_chat_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServerHello) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerHello) ProtoMessage() {}
+
+func (x *ServerHello) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_proto_msgTypes[21]
 	if x != nil {
-		if x, ok := x.Payload.(*ServerMessage_Message); ok {
-			return x.Message
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
 		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-func (x *ServerMessage) GetPeerJoined() *PeerJoined {
+// Deprecated: Use ServerHello.ProtoReflect.Descriptor instead.
+func (*ServerHello) Descriptor() ([]byte, []int) {
+	return file_proto_chat_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ServerHello) GetMinVersion() uint32 {
 	if x != nil {
-		if x, ok := x.Payload.(*ServerMessage_PeerJoined); ok {
-			return x.PeerJoined
-		}
+		return x.MinVersion
 	}
-	return nil
+	return 0
 }
 
-func (x *ServerMessage) GetPeerLeft() *PeerLeft {
+func (x *ServerHello) GetMaxVersion() uint32 {
 	if x != nil {
-		if x, ok := x.Payload.(*ServerMessage_PeerLeft); ok {
-			return x.PeerLeft
-		}
+		return x.MaxVersion
 	}
-	return nil
+	return 0
 }
 
-func (x *ServerMessage) GetRoomResponse() *RoomResponse {
+func (x *ServerHello) GetFeatures() []string {
 	if x != nil {
-		if x, ok := x.Payload.(*ServerMessage_RoomResponse); ok {
-			return x.RoomResponse
-		}
+		return x.Features
 	}
 	return nil
 }
 
-type isServerMessage_Payload interface {
-	isServerMessage_Payload()
+func (x *ServerHello) GetMaxFrameSize() uint32 {
+	if x != nil {
+		return x.MaxFrameSize
+	}
+	return 0
 }
 
-type ServerMessage_Message struct {
-	Message *ReceiveMessage `protobuf:"bytes,1,opt,name=message,proto3,oneof"`
+func (x *ServerHello) GetMaxRoomSize() uint32 {
+	if x != nil {
+		return x.MaxRoomSize
+	}
+	return 0
 }
 
-type ServerMessage_PeerJoined struct {
-	PeerJoined *PeerJoined `protobuf:"bytes,2,opt,name=peer_joined,json=peerJoined,proto3,oneof"`
+type ClientHello struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	ProtocolVersion  uint32                 `protobuf:"varint,1,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	Features         []string               `protobuf:"bytes,2,rep,name=features,proto3" json:"features,omitempty"`
+	RequiredFeatures []string               `protobuf:"bytes,3,rep,name=required_features,json=requiredFeatures,proto3" json:"required_features,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
-type ServerMessage_PeerLeft struct {
-	PeerLeft *PeerLeft `protobuf:"bytes,3,opt,name=peer_left,json=peerLeft,proto3,oneof"`
+func (x *ClientHello) Reset() {
+	*x = ClientHello{}
+	mi := &file_proto_chat_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-type ServerMessage_RoomResponse struct {
-	RoomResponse *RoomResponse `protobuf:"bytes,4,opt,name=room_response,json=roomResponse,proto3,oneof"`
+func (x *ClientHello) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ServerMessage_Message) isServerMessage_Payload() {}
+func (*ClientHello) ProtoMessage() {}
 
-func (*ServerMessage_PeerJoined) isServerMessage_Payload() {}
+func (x *ClientHello) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
 
-func (*ServerMessage_PeerLeft) isServerMessage_Payload() {}
+// Deprecated: Use ClientHello.ProtoReflect.Descriptor instead.
+func (*ClientHello) Descriptor() ([]byte, []int) {
+	return file_proto_chat_proto_rawDescGZIP(), []int{22}
+}
 
-func (*ServerMessage_RoomResponse) isServerMessage_Payload() {}
+func (x *ClientHello) GetProtocolVersion() uint32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
 
-type PeerJoined struct {
+func (x *ClientHello) GetFeatures() []string {
+	if x != nil {
+		return x.Features
+	}
+	return nil
+}
+
+func (x *ClientHello) GetRequiredFeatures() []string {
+	if x != nil {
+		return x.RequiredFeatures
+	}
+	return nil
+}
+
+type HelloRejected struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	Username      string                 `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
-	PublicKey     []byte                 `protobuf:"bytes,3,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	Code          HelloError             `protobuf:"varint,1,opt,name=code,proto3,enum=chat.HelloError" json:"code,omitempty"`
+	Detail        string                 `protobuf:"bytes,2,opt,name=detail,proto3" json:"detail,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *PeerJoined) Reset() {
-	*x = PeerJoined{}
-	mi := &file_proto_chat_proto_msgTypes[7]
+func (x *HelloRejected) Reset() {
+	*x = HelloRejected{}
+	mi := &file_proto_chat_proto_msgTypes[23]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *PeerJoined) String() string {
+func (x *HelloRejected) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PeerJoined) ProtoMessage() {}
+func (*HelloRejected) ProtoMessage() {}
 
-func (x *PeerJoined) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_chat_proto_msgTypes[7]
+func (x *HelloRejected) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_proto_msgTypes[23]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -585,54 +2101,49 @@ func (x *PeerJoined) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PeerJoined.ProtoReflect.Descriptor instead.
-func (*PeerJoined) Descriptor() ([]byte, []int) {
-	return file_proto_chat_proto_rawDescGZIP(), []int{7}
+// Deprecated: Use HelloRejected.ProtoReflect.Descriptor instead.
+func (*HelloRejected) Descriptor() ([]byte, []int) {
+	return file_proto_chat_proto_rawDescGZIP(), []int{23}
 }
 
-func (x *PeerJoined) GetUserId() string {
+func (x *HelloRejected) GetCode() HelloError {
 	if x != nil {
-		return x.UserId
+		return x.Code
 	}
-	return ""
+	return HelloError_HELLO_REQUIRED
 }
 
-func (x *PeerJoined) GetUsername() string {
+func (x *HelloRejected) GetDetail() string {
 	if x != nil {
-		return x.Username
+		return x.Detail
 	}
 	return ""
 }
 
-func (x *PeerJoined) GetPublicKey() []byte {
-	if x != nil {
-		return x.PublicKey
-	}
-	return nil
-}
-
-type PeerLeft struct {
+type ServerError struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Code          ErrorCode              `protobuf:"varint,1,opt,name=code,proto3,enum=chat.ErrorCode" json:"code,omitempty"`
+	Detail        string                 `protobuf:"bytes,2,opt,name=detail,proto3" json:"detail,omitempty"`
+	RequestId     string                 `protobuf:"bytes,3,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *PeerLeft) Reset() {
-	*x = PeerLeft{}
-	mi := &file_proto_chat_proto_msgTypes[8]
+func (x *ServerError) Reset() {
+	*x = ServerError{}
+	mi := &file_proto_chat_proto_msgTypes[24]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *PeerLeft) String() string {
+func (x *ServerError) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PeerLeft) ProtoMessage() {}
+func (*ServerError) ProtoMessage() {}
 
-func (x *PeerLeft) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_chat_proto_msgTypes[8]
+func (x *ServerError) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chat_proto_msgTypes[24]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -643,14 +2154,28 @@ func (x *PeerLeft) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PeerLeft.ProtoReflect.Descriptor instead.
-func (*PeerLeft) Descriptor() ([]byte, []int) {
-	return file_proto_chat_proto_rawDescGZIP(), []int{8}
+// Deprecated: Use ServerError.ProtoReflect.Descriptor instead.
+func (*ServerError) Descriptor() ([]byte, []int) {
+	return file_proto_chat_proto_rawDescGZIP(), []int{24}
 }
 
-func (x *PeerLeft) GetUserId() string {
+func (x *ServerError) GetCode() ErrorCode {
 	if x != nil {
-		return x.UserId
+		return x.Code
+	}
+	return ErrorCode_OK
+}
+
+func (x *ServerError) GetDetail() string {
+	if x != nil {
+		return x.Detail
+	}
+	return ""
+}
+
+func (x *ServerError) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
 	}
 	return ""
 }
@@ -662,14 +2187,19 @@ type ClientMessage struct {
 	//	*ClientMessage_JoinRoom
 	//	*ClientMessage_SendMessage
 	//	*ClientMessage_LeaveRoom
+	//	*ClientMessage_SenderKeyDistribution
+	//	*ClientMessage_Ping
+	//	*ClientMessage_ClientHello
+	//	*ClientMessage_CreateRoom
 	Payload       isClientMessage_Payload `protobuf_oneof:"payload"`
+	RequestId     string                  `protobuf:"bytes,7,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ClientMessage) Reset() {
 	*x = ClientMessage{}
-	mi := &file_proto_chat_proto_msgTypes[9]
+	mi := &file_proto_chat_proto_msgTypes[25]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -681,7 +2211,7 @@ func (x *ClientMessage) String() string {
 func (*ClientMessage) ProtoMessage() {}
 
 func (x *ClientMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_chat_proto_msgTypes[9]
+	mi := &file_proto_chat_proto_msgTypes[25]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -694,7 +2224,7 @@ func (x *ClientMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ClientMessage.ProtoReflect.Descriptor instead.
 func (*ClientMessage) Descriptor() ([]byte, []int) {
-	return file_proto_chat_proto_rawDescGZIP(), []int{9}
+	return file_proto_chat_proto_rawDescGZIP(), []int{25}
 }
 
 func (x *ClientMessage) GetPayload() isClientMessage_Payload {
@@ -731,6 +2261,49 @@ func (x *ClientMessage) GetLeaveRoom() *RoomRequest {
 	return nil
 }
 
+func (x *ClientMessage) GetSenderKeyDistribution() *SenderKeyDistribution {
+	if x != nil {
+		if x, ok := x.Payload.(*ClientMessage_SenderKeyDistribution); ok {
+			return x.SenderKeyDistribution
+		}
+	}
+	return nil
+}
+
+func (x *ClientMessage) GetPing() *Ping {
+	if x != nil {
+		if x, ok := x.Payload.(*ClientMessage_Ping); ok {
+			return x.Ping
+		}
+	}
+	return nil
+}
+
+func (x *ClientMessage) GetClientHello() *ClientHello {
+	if x != nil {
+		if x, ok := x.Payload.(*ClientMessage_ClientHello); ok {
+			return x.ClientHello
+		}
+	}
+	return nil
+}
+
+func (x *ClientMessage) GetCreateRoom() *RoomRequest {
+	if x != nil {
+		if x, ok := x.Payload.(*ClientMessage_CreateRoom); ok {
+			return x.CreateRoom
+		}
+	}
+	return nil
+}
+
+func (x *ClientMessage) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
 type isClientMessage_Payload interface {
 	isClientMessage_Payload()
 }
@@ -747,12 +2320,36 @@ type ClientMessage_LeaveRoom struct {
 	LeaveRoom *RoomRequest `protobuf:"bytes,3,opt,name=leave_room,json=leaveRoom,proto3,oneof"`
 }
 
+type ClientMessage_SenderKeyDistribution struct {
+	SenderKeyDistribution *SenderKeyDistribution `protobuf:"bytes,4,opt,name=sender_key_distribution,json=senderKeyDistribution,proto3,oneof"`
+}
+
+type ClientMessage_Ping struct {
+	Ping *Ping `protobuf:"bytes,5,opt,name=ping,proto3,oneof"`
+}
+
+type ClientMessage_ClientHello struct {
+	ClientHello *ClientHello `protobuf:"bytes,6,opt,name=client_hello,json=clientHello,proto3,oneof"`
+}
+
+type ClientMessage_CreateRoom struct {
+	CreateRoom *RoomRequest `protobuf:"bytes,8,opt,name=create_room,json=createRoom,proto3,oneof"`
+}
+
 func (*ClientMessage_JoinRoom) isClientMessage_Payload() {}
 
 func (*ClientMessage_SendMessage) isClientMessage_Payload() {}
 
 func (*ClientMessage_LeaveRoom) isClientMessage_Payload() {}
 
+func (*ClientMessage_SenderKeyDistribution) isClientMessage_Payload() {}
+
+func (*ClientMessage_Ping) isClientMessage_Payload() {}
+
+func (*ClientMessage_ClientHello) isClientMessage_Payload() {}
+
+func (*ClientMessage_CreateRoom) isClientMessage_Payload() {}
+
 var File_proto_chat_proto protoreflect.FileDescriptor
 
 const file_proto_chat_proto_rawDesc = "" +
@@ -764,55 +2361,199 @@ const file_proto_chat_proto_rawDesc = "" +
 	"\auser_id\x18\x03 \x01(\tR\x06userId\x12\x18\n" +
 	"\acontent\x18\x04 \x01(\tR\acontent\x12\x1c\n" +
 	"\ttimestamp\x18\x05 \x01(\x03R\ttimestamp\x12+\n" +
-	"\x11encrypted_content\x18\x06 \x01(\fR\x10encryptedContent\"\x96\x01\n" +
+	"\x11encrypted_content\x18\x06 \x01(\fR\x10encryptedContent\"\x8e\x03\n" +
 	"\vRoomRequest\x12\x17\n" +
 	"\aroom_id\x18\x01 \x01(\tR\x06roomId\x12\x17\n" +
 	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1a\n" +
 	"\busername\x18\x03 \x01(\tR\busername\x12\x1d\n" +
 	"\n" +
-	"public_key\x18\x04 \x01(\fR\tpublicKey\x12\x1a\n" +
-	"\bpassword\x18\x05 \x01(\tR\bpassword\"}\n" +
+	"public_key\x18\x04 \x01(\fR\tpublicKey\x12!\n" +
+	"\fidentity_key\x18\x06 \x01(\fR\videntityKey\x12#\n" +
+	"\rkey_signature\x18\a \x01(\fR\fkeySignature\x12\"\n" +
+	"\fcapabilities\x18\b \x03(\tR\fcapabilities\x12+\n" +
+	"\x11password_verifier\x18\t \x01(\fR\x10passwordVerifier\x12%\n" +
+	"\x0epassword_proof\x18\n" +
+	" \x01(\fR\rpasswordProof\x12!\n" +
+	"\fresume_token\x18\v \x01(\fR\vresumeToken\x12)\n" +
+	"\x10membership_proof\x18\f \x01(\fR\x0fmembershipProofJ\x04\b\x05\x10\x06\"\xc5\x01\n" +
 	"\fRoomResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
 	"\amessage\x18\x02 \x01(\tR\amessage\x12 \n" +
 	"\x05peers\x18\x03 \x03(\v2\n" +
 	".chat.PeerR\x05peers\x12\x17\n" +
-	"\auser_id\x18\x04 \x01(\tR\x06userId\"Z\n" +
+	"\auser_id\x18\x04 \x01(\tR\x06userId\x12!\n" +
+	"\fresume_token\x18\x05 \x01(\fR\vresumeToken\x12#\n" +
+	"\x04code\x18\x06 \x01(\x0e2\x0f.chat.ErrorCodeR\x04code\"\xf1\x01\n" +
 	"\x04Peer\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1a\n" +
 	"\busername\x18\x02 \x01(\tR\busername\x12\x1d\n" +
 	"\n" +
-	"public_key\x18\x03 \x01(\fR\tpublicKey\"S\n" +
+	"public_key\x18\x03 \x01(\fR\tpublicKey\x12!\n" +
+	"\fidentity_key\x18\x04 \x01(\fR\videntityKey\x12#\n" +
+	"\rkey_signature\x18\x05 \x01(\fR\fkeySignature\x12\"\n" +
+	"\fcapabilities\x18\x06 \x03(\tR\fcapabilities\x12)\n" +
+	"\x10membership_proof\x18\a \x01(\fR\x0fmembershipProof\"\x7f\n" +
+	"\bEnvelope\x12*\n" +
+	"\x11recipient_user_id\x18\x01 \x01(\tR\x0frecipientUserId\x12\x1e\n" +
+	"\n" +
+	"ciphertext\x18\x02 \x01(\fR\n" +
+	"ciphertext\x12'\n" +
+	"\x05suite\x18\x03 \x01(\x0e2\x11.chat.CipherSuiteR\x05suite\"\x85\x01\n" +
 	"\vSendMessage\x12\x17\n" +
-	"\aroom_id\x18\x01 \x01(\tR\x06roomId\x12+\n" +
-	"\x11encrypted_content\x18\x02 \x01(\fR\x10encryptedContent\"\xa0\x01\n" +
+	"\aroom_id\x18\x01 \x01(\tR\x06roomId\x12,\n" +
+	"\tenvelopes\x18\x03 \x03(\v2\x0e.chat.EnvelopeR\tenvelopes\x12)\n" +
+	"\x10group_ciphertext\x18\x04 \x01(\fR\x0fgroupCiphertextJ\x04\b\x02\x10\x03\"^\n" +
+	"\x15SenderKeyDistribution\x12\x17\n" +
+	"\aroom_id\x18\x01 \x01(\tR\x06roomId\x12,\n" +
+	"\tenvelopes\x18\x02 \x03(\v2\x0e.chat.EnvelopeR\tenvelopes\"e\n" +
+	"\x11SenderKeyMaterial\x12\x15\n" +
+	"\x06key_id\x18\x01 \x01(\rR\x05keyId\x12\x1b\n" +
+	"\tchain_key\x18\x02 \x01(\fR\bchainKey\x12\x1c\n" +
+	"\titeration\x18\x03 \x01(\rR\titeration\"\x81\x01\n" +
+	"\x10SenderKeyMessage\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12+\n" +
+	"\x11encrypted_content\x18\x02 \x01(\fR\x10encryptedContent\x12'\n" +
+	"\x05suite\x18\x03 \x01(\x0e2\x11.chat.CipherSuiteR\x05suite\"\xc9\x01\n" +
 	"\x0eReceiveMessage\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
 	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1a\n" +
 	"\busername\x18\x03 \x01(\tR\busername\x12+\n" +
 	"\x11encrypted_content\x18\x04 \x01(\fR\x10encryptedContent\x12\x1c\n" +
-	"\ttimestamp\x18\x05 \x01(\x03R\ttimestamp\"\xeb\x01\n" +
+	"\ttimestamp\x18\x05 \x01(\x03R\ttimestamp\x12'\n" +
+	"\x05suite\x18\x06 \x01(\x0e2\x11.chat.CipherSuiteR\x05suite\"\xb0\x06\n" +
 	"\rServerMessage\x120\n" +
 	"\amessage\x18\x01 \x01(\v2\x14.chat.ReceiveMessageH\x00R\amessage\x123\n" +
 	"\vpeer_joined\x18\x02 \x01(\v2\x10.chat.PeerJoinedH\x00R\n" +
 	"peerJoined\x12-\n" +
 	"\tpeer_left\x18\x03 \x01(\v2\x0e.chat.PeerLeftH\x00R\bpeerLeft\x129\n" +
-	"\rroom_response\x18\x04 \x01(\v2\x12.chat.RoomResponseH\x00R\froomResponseB\t\n" +
-	"\apayload\"`\n" +
+	"\rroom_response\x18\x04 \x01(\v2\x12.chat.RoomResponseH\x00R\froomResponse\x12?\n" +
+	"\x0fdelivery_report\x18\x05 \x01(\v2\x14.chat.DeliveryReportH\x00R\x0edeliveryReport\x127\n" +
+	"\n" +
+	"sender_key\x18\x06 \x01(\v2\x16.chat.SenderKeyMessageH\x00R\tsenderKey\x12<\n" +
+	"\x0eauth_challenge\x18\a \x01(\v2\x13.chat.AuthChallengeH\x00R\rauthChallenge\x12 \n" +
+	"\x04pong\x18\b \x01(\v2\n" +
+	".chat.PongH\x00R\x04pong\x12?\n" +
+	"\x0fserver_shutdown\x18\t \x01(\v2\x14.chat.ServerShutdownH\x00R\x0eserverShutdown\x126\n" +
+	"\frate_limited\x18\n" +
+	" \x01(\v2\x11.chat.RateLimitedH\x00R\vrateLimited\x12B\n" +
+	"\x10messages_dropped\x18\v \x01(\v2\x15.chat.MessagesDroppedH\x00R\x0fmessagesDropped\x126\n" +
+	"\fserver_hello\x18\f \x01(\v2\x11.chat.ServerHelloH\x00R\vserverHello\x12<\n" +
+	"\x0ehello_rejected\x18\r \x01(\v2\x13.chat.HelloRejectedH\x00R\rhelloRejected\x126\n" +
+	"\fserver_error\x18\x0e \x01(\v2\x11.chat.ServerErrorH\x00R\vserverErrorB\t\n" +
+	"\apayload\"%\n" +
+	"\rAuthChallenge\x12\x14\n" +
+	"\x05nonce\x18\x01 \x01(\fR\x05nonce\"j\n" +
+	"\x0eDeliveryResult\x12*\n" +
+	"\x11recipient_user_id\x18\x01 \x01(\tR\x0frecipientUserId\x12,\n" +
+	"\x06status\x18\x02 \x01(\x0e2\x14.chat.DeliveryStatusR\x06status\"_\n" +
+	"\x0eDeliveryReport\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\x12.\n" +
+	"\aresults\x18\x02 \x03(\v2\x14.chat.DeliveryResultR\aresults\"\xf7\x01\n" +
 	"\n" +
 	"PeerJoined\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1a\n" +
 	"\busername\x18\x02 \x01(\tR\busername\x12\x1d\n" +
 	"\n" +
-	"public_key\x18\x03 \x01(\fR\tpublicKey\"#\n" +
+	"public_key\x18\x03 \x01(\fR\tpublicKey\x12!\n" +
+	"\fidentity_key\x18\x04 \x01(\fR\videntityKey\x12#\n" +
+	"\rkey_signature\x18\x05 \x01(\fR\fkeySignature\x12\"\n" +
+	"\fcapabilities\x18\x06 \x03(\tR\fcapabilities\x12)\n" +
+	"\x10membership_proof\x18\a \x01(\fR\x0fmembershipProof\"N\n" +
 	"\bPeerLeft\x12\x17\n" +
-	"\auser_id\x18\x01 \x01(\tR\x06userId\"\xb8\x01\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12)\n" +
+	"\x06reason\x18\x02 \x01(\x0e2\x11.chat.LeaveReasonR\x06reason\"\x1c\n" +
+	"\x04Ping\x12\x14\n" +
+	"\x05nonce\x18\x01 \x01(\x04R\x05nonce\"\x1c\n" +
+	"\x04Pong\x12\x14\n" +
+	"\x05nonce\x18\x01 \x01(\x04R\x05nonce\"\x8d\x01\n" +
+	"\x0eServerShutdown\x12\x16\n" +
+	"\x06reason\x18\x01 \x01(\tR\x06reason\x126\n" +
+	"\x17reconnect_after_seconds\x18\x02 \x01(\rR\x15reconnectAfterSeconds\x12+\n" +
+	"\x11alternate_address\x18\x03 \x01(\tR\x10alternateAddress\"~\n" +
+	"\vRateLimited\x12*\n" +
+	"\x05scope\x18\x01 \x01(\x0e2\x14.chat.RateLimitScopeR\x05scope\x12$\n" +
+	"\x0eretry_after_ms\x18\x02 \x01(\rR\fretryAfterMs\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x03 \x01(\tR\trequestId\"'\n" +
+	"\x0fMessagesDropped\x12\x14\n" +
+	"\x05count\x18\x01 \x01(\rR\x05count\"\xb5\x01\n" +
+	"\vServerHello\x12\x1f\n" +
+	"\vmin_version\x18\x01 \x01(\rR\n" +
+	"minVersion\x12\x1f\n" +
+	"\vmax_version\x18\x02 \x01(\rR\n" +
+	"maxVersion\x12\x1a\n" +
+	"\bfeatures\x18\x03 \x03(\tR\bfeatures\x12$\n" +
+	"\x0emax_frame_size\x18\x04 \x01(\rR\fmaxFrameSize\x12\"\n" +
+	"\rmax_room_size\x18\x05 \x01(\rR\vmaxRoomSize\"\x81\x01\n" +
+	"\vClientHello\x12)\n" +
+	"\x10protocol_version\x18\x01 \x01(\rR\x0fprotocolVersion\x12\x1a\n" +
+	"\bfeatures\x18\x02 \x03(\tR\bfeatures\x12+\n" +
+	"\x11required_features\x18\x03 \x03(\tR\x10requiredFeatures\"M\n" +
+	"\rHelloRejected\x12$\n" +
+	"\x04code\x18\x01 \x01(\x0e2\x10.chat.HelloErrorR\x04code\x12\x16\n" +
+	"\x06detail\x18\x02 \x01(\tR\x06detail\"i\n" +
+	"\vServerError\x12#\n" +
+	"\x04code\x18\x01 \x01(\x0e2\x0f.chat.ErrorCodeR\x04code\x12\x16\n" +
+	"\x06detail\x18\x02 \x01(\tR\x06detail\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x03 \x01(\tR\trequestId\"\xbe\x03\n" +
 	"\rClientMessage\x120\n" +
 	"\tjoin_room\x18\x01 \x01(\v2\x11.chat.RoomRequestH\x00R\bjoinRoom\x126\n" +
 	"\fsend_message\x18\x02 \x01(\v2\x11.chat.SendMessageH\x00R\vsendMessage\x122\n" +
 	"\n" +
-	"leave_room\x18\x03 \x01(\v2\x11.chat.RoomRequestH\x00R\tleaveRoomB\t\n" +
-	"\apayloadB\x13Z\x11Void/proto/chatpbb\x06proto3"
+	"leave_room\x18\x03 \x01(\v2\x11.chat.RoomRequestH\x00R\tleaveRoom\x12U\n" +
+	"\x17sender_key_distribution\x18\x04 \x01(\v2\x1b.chat.SenderKeyDistributionH\x00R\x15senderKeyDistribution\x12 \n" +
+	"\x04ping\x18\x05 \x01(\v2\n" +
+	".chat.PingH\x00R\x04ping\x126\n" +
+	"\fclient_hello\x18\x06 \x01(\v2\x11.chat.ClientHelloH\x00R\vclientHello\x124\n" +
+	"\vcreate_room\x18\b \x01(\v2\x11.chat.RoomRequestH\x00R\n" +
+	"createRoom\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\a \x01(\tR\trequestIdB\t\n" +
+	"\apayload*3\n" +
+	"\vCipherSuite\x12\a\n" +
+	"\x03BOX\x10\x00\x12\v\n" +
+	"\aRATCHET\x10\x01\x12\x0e\n" +
+	"\n" +
+	"SENDER_KEY\x10\x02*]\n" +
+	"\x0eDeliveryStatus\x12\r\n" +
+	"\tDELIVERED\x10\x00\x12\x15\n" +
+	"\x11UNKNOWN_RECIPIENT\x10\x01\x12\f\n" +
+	"\bDEPARTED\x10\x02\x12\v\n" +
+	"\aDROPPED\x10\x03\x12\n" +
+	"\n" +
+	"\x06QUEUED\x10\x04*$\n" +
+	"\vLeaveReason\x12\b\n" +
+	"\x04LEFT\x10\x00\x12\v\n" +
+	"\aTIMEOUT\x10\x01*2\n" +
+	"\x0eRateLimitScope\x12\x0e\n" +
+	"\n" +
+	"CONNECTION\x10\x00\x12\x06\n" +
+	"\x02IP\x10\x01\x12\b\n" +
+	"\x04ROOM\x10\x02*R\n" +
+	"\n" +
+	"HelloError\x12\x12\n" +
+	"\x0eHELLO_REQUIRED\x10\x00\x12\x17\n" +
+	"\x13UNSUPPORTED_VERSION\x10\x01\x12\x17\n" +
+	"\x13UNSUPPORTED_FEATURE\x10\x02*\x99\x02\n" +
+	"\tErrorCode\x12\x06\n" +
+	"\x02OK\x10\x00\x12\f\n" +
+	"\bDRAINING\x10\x01\x12\x12\n" +
+	"\x0eTOO_MANY_ROOMS\x10\x02\x12\x14\n" +
+	"\x10INVALID_VERIFIER\x10\x03\x12\x14\n" +
+	"\x10INVALID_PASSWORD\x10\x04\x12\r\n" +
+	"\tROOM_FULL\x10\x05\x12\x10\n" +
+	"\fRATE_LIMITED\x10\x06\x12\x0f\n" +
+	"\vNOT_IN_ROOM\x10\a\x12\x13\n" +
+	"\x0fINVALID_MESSAGE\x10\b\x12\x13\n" +
+	"\x0fMALFORMED_FRAME\x10\t\x12\x12\n" +
+	"\x0eROOM_NOT_FOUND\x10\n" +
+	"\x12\x0f\n" +
+	"\vROOM_EXISTS\x10\v\x12\x13\n" +
+	"\x0fALREADY_IN_ROOM\x10\f\x12\x0f\n" +
+	"\vUNSUPPORTED\x10\r\x12\x0f\n" +
+	"\vBAD_REQUEST\x10\x0eB\x13Z\x11Void/proto/chatpbb\x06proto3"
 
 var (
 	file_proto_chat_proto_rawDescOnce sync.Once
@@ -826,33 +2567,82 @@ func file_proto_chat_proto_rawDescGZIP() []byte {
 	return file_proto_chat_proto_rawDescData
 }
 
-var file_proto_chat_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_proto_chat_proto_enumTypes = make([]protoimpl.EnumInfo, 6)
+var file_proto_chat_proto_msgTypes = make([]protoimpl.MessageInfo, 26)
 var file_proto_chat_proto_goTypes = []any{
-	(*Message)(nil),        // 0: chat.Message
-	(*RoomRequest)(nil),    // 1: chat.RoomRequest
-	(*RoomResponse)(nil),   // 2: chat.RoomResponse
-	(*Peer)(nil),           // 3: chat.Peer
-	(*SendMessage)(nil),    // 4: chat.SendMessage
-	(*ReceiveMessage)(nil), // 5: chat.ReceiveMessage
-	(*ServerMessage)(nil),  // 6: chat.ServerMessage
-	(*PeerJoined)(nil),     // 7: chat.PeerJoined
-	(*PeerLeft)(nil),       // 8: chat.PeerLeft
-	(*ClientMessage)(nil),  // 9: chat.ClientMessage
+	(CipherSuite)(0),              // 0: chat.CipherSuite
+	(DeliveryStatus)(0),           // 1: chat.DeliveryStatus
+	(LeaveReason)(0),              // 2: chat.LeaveReason
+	(RateLimitScope)(0),           // 3: chat.RateLimitScope
+	(HelloError)(0),               // 4: chat.HelloError
+	(ErrorCode)(0),                // 5: chat.ErrorCode
+	(*Message)(nil),               // 6: chat.Message
+	(*RoomRequest)(nil),           // 7: chat.RoomRequest
+	(*RoomResponse)(nil),          // 8: chat.RoomResponse
+	(*Peer)(nil),                  // 9: chat.Peer
+	(*Envelope)(nil),              // 10: chat.Envelope
+	(*SendMessage)(nil),           // 11: chat.SendMessage
+	(*SenderKeyDistribution)(nil), // 12: chat.SenderKeyDistribution
+	(*SenderKeyMaterial)(nil),     // 13: chat.SenderKeyMaterial
+	(*SenderKeyMessage)(nil),      // 14: chat.SenderKeyMessage
+	(*ReceiveMessage)(nil),        // 15: chat.ReceiveMessage
+	(*ServerMessage)(nil),         // 16: chat.ServerMessage
+	(*AuthChallenge)(nil),         // 17: chat.AuthChallenge
+	(*DeliveryResult)(nil),        // 18: chat.DeliveryResult
+	(*DeliveryReport)(nil),        // 19: chat.DeliveryReport
+	(*PeerJoined)(nil),            // 20: chat.PeerJoined
+	(*PeerLeft)(nil),              // 21: chat.PeerLeft
+	(*Ping)(nil),                  // 22: chat.Ping
+	(*Pong)(nil),                  // 23: chat.Pong
+	(*ServerShutdown)(nil),        // 24: chat.ServerShutdown
+	(*RateLimited)(nil),           // 25: chat.RateLimited
+	(*MessagesDropped)(nil),       // 26: chat.MessagesDropped
+	(*ServerHello)(nil),           // 27: chat.ServerHello
+	(*ClientHello)(nil),           // 28: chat.ClientHello
+	(*HelloRejected)(nil),         // 29: chat.HelloRejected
+	(*ServerError)(nil),           // 30: chat.ServerError
+	(*ClientMessage)(nil),         // 31: chat.ClientMessage
 }
 var file_proto_chat_proto_depIdxs = []int32{
-	3, // 0: chat.RoomResponse.peers:type_name -> chat.Peer
-	5, // 1: chat.ServerMessage.message:type_name -> chat.ReceiveMessage
-	7, // 2: chat.ServerMessage.peer_joined:type_name -> chat.PeerJoined
-	8, // 3: chat.ServerMessage.peer_left:type_name -> chat.PeerLeft
-	2, // 4: chat.ServerMessage.room_response:type_name -> chat.RoomResponse
-	1, // 5: chat.ClientMessage.join_room:type_name -> chat.RoomRequest
-	4, // 6: chat.ClientMessage.send_message:type_name -> chat.SendMessage
-	1, // 7: chat.ClientMessage.leave_room:type_name -> chat.RoomRequest
-	8, // [8:8] is the sub-list for method output_type
-	8, // [8:8] is the sub-list for method input_type
-	8, // [8:8] is the sub-list for extension type_name
-	8, // [8:8] is the sub-list for extension extendee
-	0, // [0:8] is the sub-list for field type_name
+	9,  // 0: chat.RoomResponse.peers:type_name -> chat.Peer
+	5,  // 1: chat.RoomResponse.code:type_name -> chat.ErrorCode
+	0,  // 2: chat.Envelope.suite:type_name -> chat.CipherSuite
+	10, // 3: chat.SendMessage.envelopes:type_name -> chat.Envelope
+	10, // 4: chat.SenderKeyDistribution.envelopes:type_name -> chat.Envelope
+	0,  // 5: chat.SenderKeyMessage.suite:type_name -> chat.CipherSuite
+	0,  // 6: chat.ReceiveMessage.suite:type_name -> chat.CipherSuite
+	15, // 7: chat.ServerMessage.message:type_name -> chat.ReceiveMessage
+	20, // 8: chat.ServerMessage.peer_joined:type_name -> chat.PeerJoined
+	21, // 9: chat.ServerMessage.peer_left:type_name -> chat.PeerLeft
+	8,  // 10: chat.ServerMessage.room_response:type_name -> chat.RoomResponse
+	19, // 11: chat.ServerMessage.delivery_report:type_name -> chat.DeliveryReport
+	14, // 12: chat.ServerMessage.sender_key:type_name -> chat.SenderKeyMessage
+	17, // 13: chat.ServerMessage.auth_challenge:type_name -> chat.AuthChallenge
+	23, // 14: chat.ServerMessage.pong:type_name -> chat.Pong
+	24, // 15: chat.ServerMessage.server_shutdown:type_name -> chat.ServerShutdown
+	25, // 16: chat.ServerMessage.rate_limited:type_name -> chat.RateLimited
+	26, // 17: chat.ServerMessage.messages_dropped:type_name -> chat.MessagesDropped
+	27, // 18: chat.ServerMessage.server_hello:type_name -> chat.ServerHello
+	29, // 19: chat.ServerMessage.hello_rejected:type_name -> chat.HelloRejected
+	30, // 20: chat.ServerMessage.server_error:type_name -> chat.ServerError
+	1,  // 21: chat.DeliveryResult.status:type_name -> chat.DeliveryStatus
+	18, // 22: chat.DeliveryReport.results:type_name -> chat.DeliveryResult
+	2,  // 23: chat.PeerLeft.reason:type_name -> chat.LeaveReason
+	3,  // 24: chat.RateLimited.scope:type_name -> chat.RateLimitScope
+	4,  // 25: chat.HelloRejected.code:type_name -> chat.HelloError
+	5,  // 26: chat.ServerError.code:type_name -> chat.ErrorCode
+	7,  // 27: chat.ClientMessage.join_room:type_name -> chat.RoomRequest
+	11, // 28: chat.ClientMessage.send_message:type_name -> chat.SendMessage
+	7,  // 29: chat.ClientMessage.leave_room:type_name -> chat.RoomRequest
+	12, // 30: chat.ClientMessage.sender_key_distribution:type_name -> chat.SenderKeyDistribution
+	22, // 31: chat.ClientMessage.ping:type_name -> chat.Ping
+	28, // 32: chat.ClientMessage.client_hello:type_name -> chat.ClientHello
+	7,  // 33: chat.ClientMessage.create_room:type_name -> chat.RoomRequest
+	34, // [34:34] is the sub-list for method output_type
+	34, // [34:34] is the sub-list for method input_type
+	34, // [34:34] is the sub-list for extension type_name
+	34, // [34:34] is the sub-list for extension extendee
+	0,  // [0:34] is the sub-list for field type_name
 }
 
 func init() { file_proto_chat_proto_init() }
@@ -860,29 +2650,44 @@ func file_proto_chat_proto_init() {
 	if File_proto_chat_proto != nil {
 		return
 	}
-	file_proto_chat_proto_msgTypes[6].OneofWrappers = []any{
+	file_proto_chat_proto_msgTypes[10].OneofWrappers = []any{
 		(*ServerMessage_Message)(nil),
 		(*ServerMessage_PeerJoined)(nil),
 		(*ServerMessage_PeerLeft)(nil),
 		(*ServerMessage_RoomResponse)(nil),
+		(*ServerMessage_DeliveryReport)(nil),
+		(*ServerMessage_SenderKey)(nil),
+		(*ServerMessage_AuthChallenge)(nil),
+		(*ServerMessage_Pong)(nil),
+		(*ServerMessage_ServerShutdown)(nil),
+		(*ServerMessage_RateLimited)(nil),
+		(*ServerMessage_MessagesDropped)(nil),
+		(*ServerMessage_ServerHello)(nil),
+		(*ServerMessage_HelloRejected)(nil),
+		(*ServerMessage_ServerError)(nil),
 	}
-	file_proto_chat_proto_msgTypes[9].OneofWrappers = []any{
+	file_proto_chat_proto_msgTypes[25].OneofWrappers = []any{
 		(*ClientMessage_JoinRoom)(nil),
 		(*ClientMessage_SendMessage)(nil),
 		(*ClientMessage_LeaveRoom)(nil),
+		(*ClientMessage_SenderKeyDistribution)(nil),
+		(*ClientMessage_Ping)(nil),
+		(*ClientMessage_ClientHello)(nil),
+		(*ClientMessage_CreateRoom)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_chat_proto_rawDesc), len(file_proto_chat_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   10,
+			NumEnums:      6,
+			NumMessages:   26,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
 		GoTypes:           file_proto_chat_proto_goTypes,
 		DependencyIndexes: file_proto_chat_proto_depIdxs,
+		EnumInfos:         file_proto_chat_proto_enumTypes,
 		MessageInfos:      file_proto_chat_proto_msgTypes,
 	}.Build()
 	File_proto_chat_proto = out.File